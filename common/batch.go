@@ -0,0 +1,126 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// MaxMultipleAccounts is the most addresses a single getMultipleAccounts request accepts
+const MaxMultipleAccounts = 100
+
+// DefaultBatchConcurrency is the number of getMultipleAccounts requests in flight at once
+var DefaultBatchConcurrency = 4
+
+// GetMultipleAccountsBatched fetches any number of accounts with getMultipleAccounts, splitting the
+// addresses into chunks of MaxMultipleAccounts and running up to concurrency chunks at once.
+// Results are in input order, with nil for accounts that do not exist.
+func GetMultipleAccountsBatched(
+	ctx context.Context,
+	rpcClient AccountFetcher,
+	addresses []solana.PublicKey,
+	concurrency int,
+) ([]*rpc.Account, error) {
+	return GetMultipleAccountsBatchedWithOpts(ctx, rpcClient, addresses, concurrency, &rpc.GetMultipleAccountsOpts{
+		Encoding: solana.EncodingBase64,
+	})
+}
+
+// GetMultipleAccountsBatchedWithOpts is GetMultipleAccountsBatched with request options, such as a data slice
+func GetMultipleAccountsBatchedWithOpts(
+	ctx context.Context,
+	rpcClient AccountFetcher,
+	addresses []solana.PublicKey,
+	concurrency int,
+	opts *rpc.GetMultipleAccountsOpts,
+) ([]*rpc.Account, error) {
+	accounts, _, err := GetMultipleAccountsBatchedWithSlot(ctx, rpcClient, addresses, concurrency, opts)
+	return accounts, err
+}
+
+// GetMultipleAccountsBatchedWithSlot is GetMultipleAccountsBatchedWithOpts that also returns the lowest
+// context slot of the requests, or 0 when there was nothing to fetch
+func GetMultipleAccountsBatchedWithSlot(
+	ctx context.Context,
+	rpcClient AccountFetcher,
+	addresses []solana.PublicKey,
+	concurrency int,
+	opts *rpc.GetMultipleAccountsOpts,
+) ([]*rpc.Account, uint64, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	accounts := make([]*rpc.Account, len(addresses))
+	if len(addresses) == 0 {
+		return accounts, 0, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		mu       sync.Mutex
+		minSlot  uint64
+	)
+	semaphore := make(chan struct{}, concurrency)
+
+	for start := 0; start < len(addresses); start += MaxMultipleAccounts {
+		end := start + MaxMultipleAccounts
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			result, err := rpcClient.GetMultipleAccountsWithOpts(ctx, addresses[start:end], opts)
+			if err == nil && len(result.Value) != end-start {
+				err = fmt.Errorf("expected %d accounts, got %d", end-start, len(result.Value))
+			}
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("failed to get accounts %d-%d: %w", start, end-1, err)
+					cancel()
+				})
+				return
+			}
+
+			// Each chunk owns a disjoint range of the output
+			copy(accounts[start:end], result.Value)
+
+			mu.Lock()
+			if minSlot == 0 || result.Context.Slot < minSlot {
+				minSlot = result.Context.Slot
+			}
+			mu.Unlock()
+		}(start, end)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, 0, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return accounts, minSlot, nil
+}
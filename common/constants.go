@@ -7,5 +7,29 @@ const (
 	TokenProgram     = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
 	Token2022Program = "TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb"
 
+	AddressLookupTableProgram = "AddressLookupTab1e1111111111111111111111111"
+
 	NativeMint = "So11111111111111111111111111111111111111112"
 )
+
+// Fee and price math constants, mirroring the cp_amm program
+const (
+	FeeDenominator  = 1_000_000_000
+	MaxFeeNumerator = 500_000_000
+	BasisPointMax   = 10_000
+	ScaleOffset     = 64
+)
+
+// Sizes of cp_amm accounts, including the discriminator
+const (
+	PoolAccountSize     = 1112
+	PositionAccountSize = 408
+	ConfigAccountSize   = 328
+)
+
+// Account discriminators of cp_amm accounts
+var (
+	PoolDiscriminator     = []byte{241, 154, 109, 4, 17, 177, 109, 188}
+	PositionDiscriminator = []byte{170, 188, 143, 228, 122, 64, 247, 208}
+	ConfigDiscriminator   = []byte{155, 12, 170, 224, 30, 250, 204, 130}
+)
@@ -0,0 +1,60 @@
+package common
+
+// ProgramErrorOffset is the code of the first cp_amm error, Anchor numbers custom errors from 6000
+const ProgramErrorOffset = 6000
+
+// programErrors are the cp_amm error names in code order
+var programErrors = []string{
+	"MathOverflow",
+	"InvalidFee",
+	"ExceededSlippage",
+	"PoolDisabled",
+	"ExceedMaxFeeBps",
+	"InvalidAdmin",
+	"AmountIsZero",
+	"TypeCastFailed",
+	"UnableToModifyActivationPoint",
+	"InvalidAuthorityToCreateThePool",
+	"InvalidActivationType",
+	"InvalidActivationPoint",
+	"InvalidQuoteMint",
+	"InvalidFeeCurve",
+	"InvalidPriceRange",
+	"PriceRangeViolation",
+	"InvalidParameters",
+	"InvalidCollectFeeMode",
+	"InvalidInput",
+	"CannotCreateTokenBadgeOnSupportedMint",
+	"InvalidTokenBadge",
+	"InvalidMinimumLiquidity",
+	"InvalidVestingInfo",
+	"InsufficientLiquidity",
+	"InvalidVestingAccount",
+	"InvalidPoolStatus",
+	"UnsupportNativeMintToken2022",
+	"InvalidRewardIndex",
+	"InvalidRewardDuration",
+	"RewardInitialized",
+	"RewardUninitialized",
+	"InvalidRewardVault",
+	"MustWithdrawnIneligibleReward",
+	"IdenticalRewardDuration",
+	"RewardCampaignInProgress",
+	"IdenticalFunder",
+	"InvalidFunder",
+	"RewardNotEnded",
+	"FeeInverseIsIncorrect",
+	"PositionIsNotEmpty",
+	"InvalidPoolCreatorAuthority",
+	"InvalidConfigType",
+	"InvalidPoolCreator",
+	"RewardVaultFrozenSkipRequired",
+}
+
+// ProgramErrorName returns the name of a cp_amm error code
+func ProgramErrorName(code uint32) (string, bool) {
+	if code < ProgramErrorOffset || code-ProgramErrorOffset >= uint32(len(programErrors)) {
+		return "", false
+	}
+	return programErrors[code-ProgramErrorOffset], true
+}
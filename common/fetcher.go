@@ -0,0 +1,146 @@
+package common
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// AccountFetcher is the subset of RPC methods the library reads accounts with.
+// *rpc.Client satisfies it, and tests can substitute an in-memory implementation.
+type AccountFetcher interface {
+	GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey, opts *rpc.GetAccountInfoOpts) (*rpc.GetAccountInfoResult, error)
+	GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey, opts *rpc.GetMultipleAccountsOpts) (*rpc.GetMultipleAccountsResult, error)
+	GetProgramAccountsWithOpts(ctx context.Context, program solana.PublicKey, opts *rpc.GetProgramAccountsOpts) (rpc.GetProgramAccountsResult, error)
+	GetTokenAccountsByOwner(ctx context.Context, owner solana.PublicKey, conf *rpc.GetTokenAccountsConfig, opts *rpc.GetTokenAccountsOpts) (*rpc.GetTokenAccountsResult, error)
+	GetTokenLargestAccounts(ctx context.Context, mint solana.PublicKey, commitment rpc.CommitmentType) (*rpc.GetTokenLargestAccountsResult, error)
+}
+
+var _ AccountFetcher = (*rpc.Client)(nil)
+
+// FetchOptions configure how accounts are read
+type FetchOptions struct {
+	Commitment       rpc.CommitmentType
+	ProgramID        solana.PublicKey
+	BatchConcurrency int
+	// MinContextSlot is sent with getAccountInfo and getMultipleAccounts.
+	// solana-go does not support it for getProgramAccounts and getTokenAccountsByOwner.
+	MinContextSlot *uint64
+	Encoding       solana.EncodingType
+	// ContextSlot receives the lowest context slot of the responses a call was built from
+	ContextSlot *uint64
+}
+
+type FetchOption func(*FetchOptions)
+
+// WithCommitment sets the commitment level of every request
+func WithCommitment(commitment rpc.CommitmentType) FetchOption {
+	return func(o *FetchOptions) {
+		o.Commitment = commitment
+	}
+}
+
+// WithProgramID overrides the cp_amm program ID, e.g. for a devnet deployment
+func WithProgramID(programID solana.PublicKey) FetchOption {
+	return func(o *FetchOptions) {
+		o.ProgramID = programID
+	}
+}
+
+// WithBatchConcurrency sets how many getMultipleAccounts requests run at once
+func WithBatchConcurrency(concurrency int) FetchOption {
+	return func(o *FetchOptions) {
+		o.BatchConcurrency = concurrency
+	}
+}
+
+// WithMinContextSlot makes the RPC node reject requests until it has reached slot
+func WithMinContextSlot(slot uint64) FetchOption {
+	return func(o *FetchOptions) {
+		o.MinContextSlot = &slot
+	}
+}
+
+// WithZstdEncoding requests account data as base64+zstd, which is smaller on the wire
+func WithZstdEncoding() FetchOption {
+	return func(o *FetchOptions) {
+		o.Encoding = solana.EncodingBase64Zstd
+	}
+}
+
+// WithContextSlot stores the context slot of the result in slot, which must be zero.
+// When a call sends several requests, the lowest slot is stored. Calls built only from
+// getProgramAccounts, which returns no context, leave it untouched.
+func WithContextSlot(slot *uint64) FetchOption {
+	return func(o *FetchOptions) {
+		o.ContextSlot = slot
+	}
+}
+
+// NewFetchOptions applies opts over the defaults
+func NewFetchOptions(opts ...FetchOption) FetchOptions {
+	options := FetchOptions{
+		ProgramID:        solana.MustPublicKeyFromBase58(DammV2ProgramID),
+		BatchConcurrency: DefaultBatchConcurrency,
+		Encoding:         solana.EncodingBase64,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// AccountInfoOpts returns getAccountInfo options for these fetch options
+func (o FetchOptions) AccountInfoOpts() *rpc.GetAccountInfoOpts {
+	return &rpc.GetAccountInfoOpts{
+		Encoding:       o.Encoding,
+		Commitment:     o.Commitment,
+		MinContextSlot: o.MinContextSlot,
+	}
+}
+
+// MultipleAccountsOpts returns getMultipleAccounts options for these fetch options
+func (o FetchOptions) MultipleAccountsOpts() *rpc.GetMultipleAccountsOpts {
+	return &rpc.GetMultipleAccountsOpts{
+		Encoding:       o.Encoding,
+		Commitment:     o.Commitment,
+		MinContextSlot: o.MinContextSlot,
+	}
+}
+
+// ProgramAccountsOpts returns getProgramAccounts options for these fetch options
+func (o FetchOptions) ProgramAccountsOpts(filters []rpc.RPCFilter, dataSlice *rpc.DataSlice) *rpc.GetProgramAccountsOpts {
+	return &rpc.GetProgramAccountsOpts{
+		Encoding:   o.Encoding,
+		Commitment: o.Commitment,
+		Filters:    filters,
+		DataSlice:  dataSlice,
+	}
+}
+
+// TokenAccountsOpts returns getTokenAccountsByOwner options for these fetch options
+func (o FetchOptions) TokenAccountsOpts(dataSlice *rpc.DataSlice) *rpc.GetTokenAccountsOpts {
+	return &rpc.GetTokenAccountsOpts{
+		Encoding:   o.Encoding,
+		Commitment: o.Commitment,
+		DataSlice:  dataSlice,
+	}
+}
+
+// RecordSlot stores slot in ContextSlot if it is lower than the slot already stored
+func (o FetchOptions) RecordSlot(slot uint64) {
+	if o.ContextSlot == nil {
+		return
+	}
+	for {
+		current := atomic.LoadUint64(o.ContextSlot)
+		if current != 0 && current <= slot {
+			return
+		}
+		if atomic.CompareAndSwapUint64(o.ContextSlot, current, slot) {
+			return
+		}
+	}
+}
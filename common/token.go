@@ -1,6 +1,7 @@
 package common
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"fmt"
@@ -9,54 +10,206 @@ import (
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
-// Retrieves all position NFT accounts owned by a user
+// Retrieves all position NFT accounts owned by a user.
+// Candidates holding exactly one token are confirmed by checking that the position PDA derived
+// from their mint exists, is owned by cp_amm and starts with the position discriminator.
 func GetAllPositionNftAccountByOwner(
 	ctx context.Context,
-	rpcClient *rpc.Client,
+	rpcClient AccountFetcher,
 	user solana.PublicKey,
+	opts ...FetchOption,
 ) ([]PositionNftAccount, error) {
-	// Get all token accounts owned by the user
-	tokenAccounts, err := rpcClient.GetTokenAccountsByOwner(
-		ctx,
-		user,
-		&rpc.GetTokenAccountsConfig{
-			ProgramId: &solana.Token2022ProgramID,
-		},
-		&rpc.GetTokenAccountsOpts{},
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get token accounts: %w", err)
+	options := NewFetchOptions(opts...)
+
+	var candidates []PositionNftAccount
+
+	// Position NFTs are Token-2022 mints, but search legacy SPL token accounts too
+	for _, programID := range []solana.PublicKey{solana.Token2022ProgramID, solana.TokenProgramID} {
+		programID := programID
+
+		// Only the mint and amount are needed, so slice the data to keep the payload small
+		tokenAccounts, err := rpcClient.GetTokenAccountsByOwner(
+			ctx,
+			user,
+			&rpc.GetTokenAccountsConfig{
+				ProgramId: &programID,
+			},
+			options.TokenAccountsOpts(&rpc.DataSlice{Offset: uint64Ptr(0), Length: uint64Ptr(72)}),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token accounts: %w", err)
+		}
+		options.RecordSlot(tokenAccounts.Context.Slot)
+
+		for _, tokenAccount := range tokenAccounts.Value {
+			data := tokenAccount.Account.Data.GetBinary()
+			if len(data) < 72 {
+				continue
+			}
+
+			// Check if the account has exactly 1 token (NFT)
+			if binary.LittleEndian.Uint64(data[64:72]) == 1 {
+				candidates = append(candidates, PositionNftAccount{
+					PositionNft:        solana.PublicKeyFromBytes(data[0:32]),
+					PositionNftAccount: tokenAccount.Pubkey,
+				})
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return []PositionNftAccount{}, nil
+	}
+
+	positionAddresses := make([]solana.PublicKey, len(candidates))
+	for i, candidate := range candidates {
+		positionAddress, err := derivePositionAddress(candidate.PositionNft, options.ProgramID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive position address: %w", err)
+		}
+		positionAddresses[i] = positionAddress
 	}
 
-	var userPositionNftAccounts []PositionNftAccount
+	// Only the discriminator is needed, the owner is returned even with the data sliced.
+	// The size is not checked, the program is the only one able to create accounts at its PDAs.
+	multipleAccountsOpts := options.MultipleAccountsOpts()
+	multipleAccountsOpts.DataSlice = &rpc.DataSlice{Offset: uint64Ptr(0), Length: uint64Ptr(uint64(len(PositionDiscriminator)))}
+
+	positionAccounts, slot, err := GetMultipleAccountsBatchedWithSlot(ctx, rpcClient, positionAddresses, options.BatchConcurrency, multipleAccountsOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position accounts: %w", err)
+	}
+	options.RecordSlot(slot)
 
-	// Iterate through token accounts
-	for _, tokenAccount := range tokenAccounts.Value {
-		data := tokenAccount.Account.Data.GetBinary()
-		if len(data) < 165 { // Minimum size for token account data
+	userPositionNftAccounts := make([]PositionNftAccount, 0, len(candidates))
+	for i, account := range positionAccounts {
+		// Other NFTs held by the user have no valid position behind them
+		if account == nil || !account.Owner.Equals(options.ProgramID) || !bytes.Equal(account.Data.GetBinary(), PositionDiscriminator) {
 			continue
 		}
+		userPositionNftAccounts = append(userPositionNftAccounts, candidates[i])
+	}
+
+	return userPositionNftAccounts, nil
+}
+
+// derivePositionAddress derives the position PDA from a position NFT mint
+func derivePositionAddress(positionNft solana.PublicKey, programID solana.PublicKey) (solana.PublicKey, error) {
+	seeds := [][]byte{[]byte("position"), positionNft.Bytes()}
+	address, _, err := solana.FindProgramAddress(seeds, programID)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	return address, nil
+}
 
-		// Parse token account data
-		layout := TokenAccountLayout{
-			Mint:            solana.PublicKeyFromBytes(data[0:32]),
-			Owner:           solana.PublicKeyFromBytes(data[32:64]),
-			Amount:          binary.LittleEndian.Uint64(data[64:72]),
-			Delegate:        solana.PublicKeyFromBytes(data[72:104]),
-			State:           data[104],
-			IsNative:        binary.LittleEndian.Uint64(data[105:113]),
-			DelegatedAmount: binary.LittleEndian.Uint64(data[113:121]),
-			CloseAuthority:  solana.PublicKeyFromBytes(data[121:153]),
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+// Minimum size of token account data, Token-2022 accounts may carry extensions after it
+const TokenAccountSize = 165
+
+// Decodes the base layout of an SPL token or Token-2022 account
+func DecodeTokenAccount(data []byte) (*TokenAccountLayout, error) {
+	if len(data) < TokenAccountSize {
+		return nil, fmt.Errorf("token account data too short: %d bytes", len(data))
+	}
+
+	tokenAccount := &TokenAccountLayout{
+		Mint:            solana.PublicKeyFromBytes(data[0:32]),
+		Owner:           solana.PublicKeyFromBytes(data[32:64]),
+		Amount:          binary.LittleEndian.Uint64(data[64:72]),
+		State:           data[108],
+		DelegatedAmount: binary.LittleEndian.Uint64(data[121:129]),
+	}
+
+	// Optional fields are a u32 tag followed by the value, which is zero when the tag is unset
+	if binary.LittleEndian.Uint32(data[72:76]) == 1 {
+		tokenAccount.Delegate = solana.PublicKeyFromBytes(data[76:108])
+	}
+	if binary.LittleEndian.Uint32(data[109:113]) == 1 {
+		tokenAccount.IsNative = binary.LittleEndian.Uint64(data[113:121])
+	}
+	if binary.LittleEndian.Uint32(data[129:133]) == 1 {
+		tokenAccount.CloseAuthority = solana.PublicKeyFromBytes(data[133:165])
+	}
+
+	return tokenAccount, nil
+}
+
+// Size of the base mint layout, Token-2022 mints may carry extensions after it
+const MintSize = 82
+
+// Names of Token-2022 extension types
+var tokenExtensionNames = map[uint16]string{
+	1:  "TransferFeeConfig",
+	2:  "TransferFeeAmount",
+	3:  "MintCloseAuthority",
+	4:  "ConfidentialTransferMint",
+	5:  "ConfidentialTransferAccount",
+	6:  "DefaultAccountState",
+	7:  "ImmutableOwner",
+	8:  "MemoTransfer",
+	9:  "NonTransferable",
+	10: "InterestBearingConfig",
+	11: "CpiGuard",
+	12: "PermanentDelegate",
+	13: "NonTransferableAccount",
+	14: "TransferHook",
+	15: "TransferHookAccount",
+	16: "ConfidentialTransferFeeConfig",
+	17: "ConfidentialTransferFeeAmount",
+	18: "MetadataPointer",
+	19: "TokenMetadata",
+	20: "GroupPointer",
+	21: "TokenGroup",
+	22: "GroupMemberPointer",
+	23: "TokenGroupMember",
+	24: "ConfidentialMintBurn",
+	25: "ScaledUiAmount",
+	26: "Pausable",
+	27: "PausableAccount",
+}
+
+// Decodes an SPL token or Token-2022 mint, including Token-2022 extensions
+func DecodeMint(mint solana.PublicKey, owner solana.PublicKey, data []byte) (*MintInfo, error) {
+	if len(data) < MintSize {
+		return nil, fmt.Errorf("mint data too short: %d bytes", len(data))
+	}
+
+	mintInfo := &MintInfo{
+		Mint:         mint,
+		TokenProgram: owner,
+		Supply:       binary.LittleEndian.Uint64(data[36:44]),
+		Decimals:     data[44],
+		Extensions:   []TokenExtension{},
+	}
+
+	// Extensions start after the account type byte, which sits at the end of the padded account layout
+	if len(data) <= TokenAccountSize+1 {
+		return mintInfo, nil
+	}
+
+	tlv := data[TokenAccountSize+1:]
+	for len(tlv) >= 4 {
+		extensionType := binary.LittleEndian.Uint16(tlv[0:2])
+		length := int(binary.LittleEndian.Uint16(tlv[2:4]))
+		if extensionType == 0 || len(tlv) < 4+length {
+			break
 		}
 
-		// Check if the account has exactly 1 token (NFT)
-		if layout.Amount == 1 {
-			userPositionNftAccounts = append(userPositionNftAccounts, PositionNftAccount{
-				PositionNft:        layout.Mint,
-				PositionNftAccount: tokenAccount.Pubkey,
-			})
+		name, ok := tokenExtensionNames[extensionType]
+		if !ok {
+			name = fmt.Sprintf("Unknown(%d)", extensionType)
 		}
+		mintInfo.Extensions = append(mintInfo.Extensions, TokenExtension{
+			Type: extensionType,
+			Name: name,
+			Data: append([]byte(nil), tlv[4:4+length]...),
+		})
+		tlv = tlv[4+length:]
 	}
 
-	return userPositionNftAccounts, nil
+	return mintInfo, nil
 }
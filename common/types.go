@@ -1,6 +1,10 @@
 package common
 
 import (
+	"fmt"
+	"math/big"
+	"time"
+
 	"github.com/gagliardetto/solana-go"
 	"lukechampine.com/uint128"
 )
@@ -15,6 +19,11 @@ type BaseFeeStruct struct {
 	Padding1          uint64
 }
 
+const (
+	FeeSchedulerModeLinear      uint8 = 0
+	FeeSchedulerModeExponential uint8 = 1
+)
+
 type DynamicFeeStruct struct {
 	Initialized              uint8
 	Padding                  [7]uint8
@@ -68,6 +77,49 @@ type RewardInfo struct {
 	CumulativeSecondsWithEmptyLiquidity uint64
 }
 
+type CollectFeeMode uint8
+
+const (
+	// Fees are charged on the output token, so in both tokens
+	CollectFeeModeBothToken CollectFeeMode = 0
+	// Fees are always charged in token B
+	CollectFeeModeOnlyB CollectFeeMode = 1
+)
+
+// Validate returns an error if the mode is not one the program supports
+func (m CollectFeeMode) Validate() error {
+	switch m {
+	case CollectFeeModeBothToken, CollectFeeModeOnlyB:
+		return nil
+	default:
+		return fmt.Errorf("invalid collect fee mode: %d", uint8(m))
+	}
+}
+
+func (m CollectFeeMode) String() string {
+	switch m {
+	case CollectFeeModeBothToken:
+		return "BothToken"
+	case CollectFeeModeOnlyB:
+		return "OnlyB"
+	default:
+		return fmt.Sprintf("CollectFeeMode(%d)", uint8(m))
+	}
+}
+
+type TradeDirection uint8
+
+const (
+	TradeDirectionAtoB TradeDirection = 0
+	TradeDirectionBtoA TradeDirection = 1
+)
+
+type FeeMode struct {
+	FeesOnInput  bool
+	FeesOnTokenA bool
+	HasReferral  bool
+}
+
 type Pool struct {
 	PoolFees               PoolFeesStruct
 	TokenAMint             solana.PublicKey
@@ -90,7 +142,7 @@ type Pool struct {
 	PoolStatus             uint8
 	TokenAFlag             uint8
 	TokenBFlag             uint8
-	CollectFeeMode         uint8
+	CollectFeeMode         CollectFeeMode
 	PoolType               uint8
 	Padding0               [2]uint8
 	FeeAPerLiquidity       [32]uint8
@@ -101,6 +153,59 @@ type Pool struct {
 	RewardInfos            [2]RewardInfo
 }
 
+type BaseFeeConfig struct {
+	CliffFeeNumerator uint64
+	FeeSchedulerMode  uint8
+	Padding           [5]uint8
+	NumberOfPeriod    uint16
+	PeriodFrequency   uint64
+	ReductionFactor   uint64
+}
+
+type DynamicFeeConfig struct {
+	Initialized              uint8
+	Padding                  [7]uint8
+	MaxVolatilityAccumulator uint32
+	VariableFeeControl       uint32
+	BinStep                  uint16
+	FilterPeriod             uint16
+	DecayPeriod              uint16
+	ReductionFactor          uint16
+	Padding1                 [8]uint8
+	BinStepU128              uint128.Uint128
+}
+
+type PoolFeesConfig struct {
+	BaseFee            BaseFeeConfig
+	DynamicFee         DynamicFeeConfig
+	ProtocolFeePercent uint8
+	PartnerFeePercent  uint8
+	ReferralFeePercent uint8
+	Padding0           [5]uint8
+	Padding1           [5]uint64
+}
+
+// Config is the template pools are created from
+type Config struct {
+	VaultConfigKey       solana.PublicKey
+	PoolCreatorAuthority solana.PublicKey
+	PoolFees             PoolFeesConfig
+	ActivationType       uint8
+	CollectFeeMode       CollectFeeMode
+	ConfigType           uint8
+	Padding0             [5]uint8
+	Index                uint64
+	SqrtMinPrice         uint128.Uint128
+	SqrtMaxPrice         uint128.Uint128
+	Padding1             [10]uint64
+}
+
+// Pool statuses
+const (
+	PoolStatusEnabled  uint8 = 0
+	PoolStatusDisabled uint8 = 1
+)
+
 type PositionNftAccount struct {
 	PositionNft        solana.PublicKey
 	PositionNftAccount solana.PublicKey
@@ -117,6 +222,13 @@ type TokenAccountLayout struct {
 	CloseAuthority  solana.PublicKey
 }
 
+// States of a token account
+const (
+	TokenAccountStateUninitialized uint8 = 0
+	TokenAccountStateInitialized   uint8 = 1
+	TokenAccountStateFrozen        uint8 = 2
+)
+
 type PositionMetrics struct {
 	TotalClaimedAFee uint64
 	TotalClaimedBFee uint64
@@ -147,10 +259,13 @@ type PositionResult struct {
 	PositionNftAccount solana.PublicKey
 	Position           solana.PublicKey
 	PositionState      PositionState
+	// Wallet holding the position NFT, when known
+	Owner solana.PublicKey
 }
 
 const (
-	LIQUIDITY_SCALE = 64
+	LIQUIDITY_SCALE   = 128
+	REWARD_RATE_SCALE = 64
 )
 
 type UnclaimReward struct {
@@ -158,3 +273,193 @@ type UnclaimReward struct {
 	FeeTokenB uint128.Uint128
 	Rewards   []uint128.Uint128
 }
+
+type TokenAmounts struct {
+	AmountA uint64
+	AmountB uint64
+}
+
+type PositionValue struct {
+	Unlocked        TokenAmounts
+	Vested          TokenAmounts
+	PermanentLocked TokenAmounts
+	Total           TokenAmounts
+	Claimable       UnclaimReward
+	// Totals including claimable fees, only set when token decimals are known
+	TotalInTokenA *big.Rat `json:",omitempty"`
+	TotalInTokenB *big.Rat `json:",omitempty"`
+}
+
+type SwapQuote struct {
+	AmountIn          uint64
+	AmountOut         uint64
+	NextSqrtPrice     uint128.Uint128
+	TradeFeeNumerator uint64
+	FeeMode           FeeMode
+	// Fee breakdown, charged in token A when FeeMode.FeesOnTokenA is set and in token B otherwise
+	LpFee       uint64
+	ProtocolFee uint64
+	PartnerFee  uint64
+	ReferralFee uint64
+}
+
+type DepthLevel struct {
+	ChangeBps uint64
+	// Price moved down: token A sold into the pool and token B paid out of it
+	BidSqrtPrice uint128.Uint128
+	BidAmountA   uint64
+	BidAmountB   uint64
+	// Price moved up: token B sold into the pool and token A paid out of it
+	AskSqrtPrice uint128.Uint128
+	AskAmountA   uint64
+	AskAmountB   uint64
+}
+
+// Values are in the smallest unit of token B, priced at the pool's current price
+type ImpermanentLoss struct {
+	EntryAmounts  TokenAmounts
+	CurrentPrice  *big.Rat
+	HoldValue     *big.Rat
+	PositionValue *big.Rat
+	// Claimed plus pending fees
+	FeeValue *big.Rat
+	// PositionValue - HoldValue, negative when providing liquidity lost value
+	ImpermanentLoss *big.Rat
+	// ImpermanentLoss relative to HoldValue, in percent
+	ImpermanentLossPercent *big.Rat
+	// PositionValue + FeeValue - HoldValue
+	NetProfit *big.Rat
+	// Fees needed for providing liquidity to match holding
+	BreakEvenFee *big.Rat
+}
+
+type PoolSnapshot struct {
+	Pool           solana.PublicKey
+	Timestamp      int64
+	Slot           uint64
+	SqrtPrice      uint128.Uint128
+	SqrtMinPrice   uint128.Uint128
+	SqrtMaxPrice   uint128.Uint128
+	Liquidity      uint128.Uint128
+	CollectFeeMode CollectFeeMode
+	TotalLpAFee    uint128.Uint128
+	TotalLpBFee    uint128.Uint128
+}
+
+// Values are in the smallest unit of token B
+type FeeAPR struct {
+	ElapsedSeconds int64
+	LpAFee         uint128.Uint128
+	LpBFee         uint128.Uint128
+	FeeValue       *big.Rat
+	// Average of the start and end TVL
+	TVL *big.Rat
+	// Annualized fee value over TVL, in percent
+	APR *big.Rat
+}
+
+type PositionFeeAPR struct {
+	Pool FeeAPR
+	// Position liquidity over pool liquidity
+	Share *big.Rat
+	// Share of the fee value over the period
+	PositionFeeValue *big.Rat
+	PositionValue    *big.Rat
+	// Annualized position fee value over position value, in percent
+	APR *big.Rat
+}
+
+type PoolResult struct {
+	Pool      solana.PublicKey
+	PoolState Pool
+}
+
+type PoolSortBy int
+
+const (
+	// Highest liquidity first
+	PoolSortByLiquidity PoolSortBy = iota
+	// Lowest base fee first
+	PoolSortByFee
+)
+
+type TokenExtension struct {
+	Type uint16
+	Name string
+	Data []byte
+}
+
+type MintInfo struct {
+	Mint         solana.PublicKey
+	TokenProgram solana.PublicKey
+	Supply       uint64
+	Decimals     uint8
+	// Token-2022 extensions, empty for SPL token mints
+	Extensions []TokenExtension
+}
+
+type PoolInfo struct {
+	Pool      solana.PublicKey
+	PoolState Pool
+	// Context slot all accounts were read at
+	Slot          uint64
+	VaultBalances TokenAmounts
+	TokenAMint    MintInfo
+	TokenBMint    MintInfo
+	// Mints of initialized rewards, in reward index order
+	RewardMints []MintInfo
+	// Price of token A in token B, adjusted for decimals
+	Price *big.Rat
+}
+
+type PoolUpdate struct {
+	Pool      solana.PublicKey
+	PoolState Pool
+	// Context slot the state was read at
+	Slot uint64
+}
+
+type PositionUpdate struct {
+	Position      solana.PublicKey
+	PositionState PositionState
+	// Context slot the state was read at
+	Slot uint64
+}
+
+type PositionOwner struct {
+	Position    solana.PublicKey
+	PositionNft solana.PublicKey
+	// Token account currently holding the position NFT
+	PositionNftAccount solana.PublicKey
+	// Wallet owning PositionNftAccount
+	Owner    solana.PublicKey
+	Frozen   bool
+	Delegate solana.PublicKey
+	// Delegated is true when Delegate may transfer the NFT
+	Delegated bool
+}
+
+type MintFees struct {
+	LpFee       *big.Int
+	ProtocolFee *big.Int
+	PartnerFee  *big.Int
+}
+
+type ProtocolStats struct {
+	ProgramID solana.PublicKey
+	CrawledAt time.Time
+	Pools     int
+	// Pool counts keyed by "enabled" or "disabled"
+	PoolsByStatus map[string]int
+	// Pool counts keyed by CollectFeeMode name
+	PoolsByCollectFeeMode map[string]int
+	PoolsWithDynamicFee   int
+	Positions             int
+	TotalLiquidity        *big.Int
+	// Liquidity split by lock state, summed over all positions
+	PermanentLockedLiquidity *big.Int
+	VestedLiquidity          *big.Int
+	UnlockedLiquidity        *big.Int
+	// Fees collected since pool creation, from PoolMetrics, keyed by mint
+	Fees map[string]*MintFees
+}
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Errors returned when an account is missing or fails validation, match them with errors.Is
+var (
+	ErrNotFound           = errors.New("account not found")
+	ErrWrongOwner         = errors.New("account has wrong owner")
+	ErrWrongSize          = errors.New("account has wrong size")
+	ErrWrongDiscriminator = errors.New("account has wrong discriminator")
+)
+
+// ValidateAccount checks that account exists, is owned by owner, holds exactly size bytes
+// and starts with discriminator, so spoofed accounts with copied bytes are rejected
+func ValidateAccount(account *rpc.Account, owner solana.PublicKey, size int, discriminator []byte) error {
+	if account == nil {
+		return ErrNotFound
+	}
+	if !account.Owner.Equals(owner) {
+		return fmt.Errorf("%w: owned by %s, expected %s", ErrWrongOwner, account.Owner, owner)
+	}
+
+	data := account.Data.GetBinary()
+	if len(data) != size {
+		return fmt.Errorf("%w: %d bytes, expected %d", ErrWrongSize, len(data), size)
+	}
+	if !bytes.Equal(data[:len(discriminator)], discriminator) {
+		return fmt.Errorf("%w: %v", ErrWrongDiscriminator, data[:len(discriminator)])
+	}
+
+	return nil
+}
+
+// ValidateTokenAccount checks that account exists, is owned by the SPL token or Token-2022 program
+// and is large enough to hold a token account
+func ValidateTokenAccount(account *rpc.Account) error {
+	if account == nil {
+		return ErrNotFound
+	}
+	if !account.Owner.Equals(solana.TokenProgramID) && !account.Owner.Equals(solana.Token2022ProgramID) {
+		return fmt.Errorf("%w: owned by %s, expected a token program", ErrWrongOwner, account.Owner)
+	}
+	if size := len(account.Data.GetBinary()); size < TokenAccountSize {
+		return fmt.Errorf("%w: %d bytes, expected at least %d", ErrWrongSize, size, TokenAccountSize)
+	}
+
+	return nil
+}
+
+// ValidateMint checks that account exists, is owned by the SPL token or Token-2022 program
+// and is large enough to hold a mint
+func ValidateMint(account *rpc.Account) error {
+	if account == nil {
+		return ErrNotFound
+	}
+	if !account.Owner.Equals(solana.TokenProgramID) && !account.Owner.Equals(solana.Token2022ProgramID) {
+		return fmt.Errorf("%w: owned by %s, expected a token program", ErrWrongOwner, account.Owner)
+	}
+	if size := len(account.Data.GetBinary()); size < MintSize {
+		return fmt.Errorf("%w: %d bytes, expected at least %d", ErrWrongSize, size, MintSize)
+	}
+
+	return nil
+}
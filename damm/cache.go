@@ -0,0 +1,309 @@
+package damm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/dannwee/dbc-go/instructions"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// defaultCacheFetchTimeout bounds a shared fetch when CacheOptions.FetchTimeout is zero
+const defaultCacheFetchTimeout = 30 * time.Second
+
+// CacheOptions configure when cached accounts expire. A zero TTL or MaxSlotDistance disables that limit.
+type CacheOptions struct {
+	// TTL is how long an entry is served after it was stored
+	TTL time.Duration
+	// MaxSlotDistance is how many slots an entry may fall behind the newest slot the cache has seen
+	MaxSlotDistance uint64
+	// FetchTimeout bounds a fetch shared by concurrent lookups, which outlives the caller that started it.
+	// Defaults to 30s.
+	FetchTimeout time.Duration
+}
+
+// CacheStats counts cache lookups since the cache was created
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Misses that waited on a fetch already in flight instead of sending their own
+	Deduplicated uint64
+	// States pushed in through the Update methods
+	Updates uint64
+	Entries int
+}
+
+type cacheKind uint8
+
+const (
+	cacheKindPool cacheKind = iota
+	cacheKindPosition
+	cacheKindConfig
+)
+
+func (k cacheKind) String() string {
+	switch k {
+	case cacheKindPool:
+		return "pool"
+	case cacheKindPosition:
+		return "position"
+	default:
+		return "config"
+	}
+}
+
+type cacheKey struct {
+	kind    cacheKind
+	address solana.PublicKey
+}
+
+type cacheEntry struct {
+	value    interface{}
+	slot     uint64
+	storedAt time.Time
+}
+
+// accountDecoder validates and decodes an account of the cp_amm program programID
+type accountDecoder func(account *rpc.Account, programID solana.PublicKey) (interface{}, error)
+
+// cacheCall is a fetch in flight that concurrent lookups of the same key wait on
+type cacheCall struct {
+	done  chan struct{}
+	entry cacheEntry
+	err   error
+}
+
+// AccountCache caches decoded pool, position and config accounts with the slot they were read at.
+// Concurrent lookups of the same account share one request. Returned values are shared between
+// callers and must not be modified.
+type AccountCache struct {
+	client  *Client
+	options CacheOptions
+
+	mu           sync.Mutex
+	entries      map[cacheKey]cacheEntry
+	calls        map[cacheKey]*cacheCall
+	latestSlot   uint64
+	hits         uint64
+	misses       uint64
+	deduplicated uint64
+	updates      uint64
+}
+
+// NewAccountCache creates a cache reading through client
+func NewAccountCache(client *Client, options CacheOptions) *AccountCache {
+	return &AccountCache{
+		client:  client,
+		options: options,
+		entries: make(map[cacheKey]cacheEntry),
+		calls:   make(map[cacheKey]*cacheCall),
+	}
+}
+
+// GetPool returns the pool and the slot it was read at
+func (c *AccountCache) GetPool(ctx context.Context, poolAddress solana.PublicKey) (*common.Pool, uint64, error) {
+	value, slot, err := c.get(ctx, cacheKey{cacheKindPool, poolAddress}, func(account *rpc.Account, programID solana.PublicKey) (interface{}, error) {
+		return instructions.DecodePool(account, programID)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return value.(*common.Pool), slot, nil
+}
+
+// GetPosition returns the position and the slot it was read at
+func (c *AccountCache) GetPosition(ctx context.Context, positionAddress solana.PublicKey) (*common.PositionState, uint64, error) {
+	value, slot, err := c.get(ctx, cacheKey{cacheKindPosition, positionAddress}, func(account *rpc.Account, programID solana.PublicKey) (interface{}, error) {
+		return instructions.DecodePosition(account, programID)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return value.(*common.PositionState), slot, nil
+}
+
+// GetConfig returns the config and the slot it was read at
+func (c *AccountCache) GetConfig(ctx context.Context, configAddress solana.PublicKey) (*common.Config, uint64, error) {
+	value, slot, err := c.get(ctx, cacheKey{cacheKindConfig, configAddress}, func(account *rpc.Account, programID solana.PublicKey) (interface{}, error) {
+		return instructions.DecodeConfig(account, programID)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return value.(*common.Config), slot, nil
+}
+
+// UpdatePool stores a pool state received elsewhere, e.g. from a subscription.
+// It is ignored if the cache already holds a state from a later slot.
+func (c *AccountCache) UpdatePool(poolAddress solana.PublicKey, pool *common.Pool, slot uint64) bool {
+	return c.update(cacheKey{cacheKindPool, poolAddress}, pool, slot)
+}
+
+// UpdatePosition stores a position state received elsewhere, e.g. from a subscription.
+// It is ignored if the cache already holds a state from a later slot.
+func (c *AccountCache) UpdatePosition(positionAddress solana.PublicKey, position *common.PositionState, slot uint64) bool {
+	return c.update(cacheKey{cacheKindPosition, positionAddress}, position, slot)
+}
+
+// UpdateConfig stores a config received elsewhere.
+// It is ignored if the cache already holds a state from a later slot.
+func (c *AccountCache) UpdateConfig(configAddress solana.PublicKey, config *common.Config, slot uint64) bool {
+	return c.update(cacheKey{cacheKindConfig, configAddress}, config, slot)
+}
+
+// ObserveSlot advances the newest slot the cache has seen, expiring entries that fall too far behind
+func (c *AccountCache) ObserveSlot(slot uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if slot > c.latestSlot {
+		c.latestSlot = slot
+	}
+}
+
+// Invalidate drops every cached state of address
+func (c *AccountCache) Invalidate(address solana.PublicKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, kind := range []cacheKind{cacheKindPool, cacheKindPosition, cacheKindConfig} {
+		delete(c.entries, cacheKey{kind, address})
+	}
+}
+
+// InvalidateAll empties the cache
+func (c *AccountCache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[cacheKey]cacheEntry)
+}
+
+// Prune drops expired entries and returns how many were dropped
+func (c *AccountCache) Prune() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	pruned := 0
+	for key, entry := range c.entries {
+		if !c.fresh(entry, now) {
+			delete(c.entries, key)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Stats returns the hit and miss counters
+func (c *AccountCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Hits:         c.hits,
+		Misses:       c.misses,
+		Deduplicated: c.deduplicated,
+		Updates:      c.updates,
+		Entries:      len(c.entries),
+	}
+}
+
+// fresh reports whether entry is within the TTL and slot distance, with c.mu held
+func (c *AccountCache) fresh(entry cacheEntry, now time.Time) bool {
+	if c.options.TTL > 0 && now.Sub(entry.storedAt) > c.options.TTL {
+		return false
+	}
+	if c.options.MaxSlotDistance > 0 && c.latestSlot > entry.slot+c.options.MaxSlotDistance {
+		return false
+	}
+	return true
+}
+
+// store keeps entry unless a later slot is already cached, with c.mu held
+func (c *AccountCache) store(key cacheKey, entry cacheEntry) bool {
+	if existing, ok := c.entries[key]; ok && existing.slot > entry.slot {
+		return false
+	}
+	c.entries[key] = entry
+	if entry.slot > c.latestSlot {
+		c.latestSlot = entry.slot
+	}
+	return true
+}
+
+func (c *AccountCache) update(key cacheKey, value interface{}, slot uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updates++
+	return c.store(key, cacheEntry{value: value, slot: slot, storedAt: time.Now()})
+}
+
+// get returns the cached entry for key, or fetches and decodes it, sharing the request with concurrent callers.
+// Each caller stops waiting when its own ctx is done, the shared fetch is only bounded by FetchTimeout.
+func (c *AccountCache) get(ctx context.Context, key cacheKey, decode accountDecoder) (interface{}, uint64, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && c.fresh(entry, time.Now()) {
+		c.hits++
+		c.mu.Unlock()
+		return entry.value, entry.slot, nil
+	}
+	c.misses++
+
+	call, ok := c.calls[key]
+	if ok {
+		c.deduplicated++
+	} else {
+		call = &cacheCall{done: make(chan struct{})}
+		c.calls[key] = call
+		go c.share(ctx, key, call, decode)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.entry.value, call.entry.slot, call.err
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	}
+}
+
+// share runs the fetch of call, detached from the cancellation of the caller that started it
+func (c *AccountCache) share(ctx context.Context, key cacheKey, call *cacheCall, decode accountDecoder) {
+	timeout := c.options.FetchTimeout
+	if timeout <= 0 {
+		timeout = defaultCacheFetchTimeout
+	}
+	fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	call.entry, call.err = c.fetch(fetchCtx, key, decode)
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	if call.err == nil {
+		c.store(key, call.entry)
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
+
+func (c *AccountCache) fetch(ctx context.Context, key cacheKey, decode accountDecoder) (cacheEntry, error) {
+	options := common.NewFetchOptions(c.client.fetchOptions(nil)...)
+
+	account, err := c.client.fetcher.GetAccountInfoWithOpts(ctx, key.address, options.AccountInfoOpts())
+	if errors.Is(err, rpc.ErrNotFound) || (err == nil && (account == nil || account.Value == nil)) {
+		return cacheEntry{}, fmt.Errorf("%s account %s: %w", key.kind, key.address, common.ErrNotFound)
+	}
+	if err != nil {
+		return cacheEntry{}, fmt.Errorf("failed to get %s account: %w", key.kind, err)
+	}
+
+	value, err := decode(account.Value, options.ProgramID)
+	if err != nil {
+		return cacheEntry{}, fmt.Errorf("invalid %s account %s: %w", key.kind, key.address, err)
+	}
+
+	return cacheEntry{value: value, slot: account.Context.Slot, storedAt: time.Now()}, nil
+}
@@ -0,0 +1,84 @@
+package damm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// blockingFetcher answers getAccountInfo with a zeroed config account once release is closed
+type blockingFetcher struct {
+	common.AccountFetcher
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (f *blockingFetcher) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey, opts *rpc.GetAccountInfoOpts) (*rpc.GetAccountInfoResult, error) {
+	f.calls.Add(1)
+	select {
+	case <-f.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	data := make([]byte, common.ConfigAccountSize)
+	copy(data, common.ConfigDiscriminator)
+	return &rpc.GetAccountInfoResult{
+		RPCContext: rpc.RPCContext{Context: rpc.Context{Slot: 42}},
+		Value: &rpc.Account{
+			Owner: solana.MustPublicKeyFromBase58(common.DammV2ProgramID),
+			Data:  rpc.DataBytesOrJSONFromBytes(data),
+		},
+	}, nil
+}
+
+func TestAccountCacheSharedFetchOutlivesCanceledCaller(t *testing.T) {
+	fetcher := &blockingFetcher{release: make(chan struct{})}
+	cache := NewAccountCache(NewClient(fetcher), CacheOptions{})
+	config := solana.NewWallet().PublicKey()
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, _, err := cache.GetConfig(first, config)
+		firstErr <- err
+	}()
+
+	// Wait for the first caller to start the shared fetch before joining it
+	for fetcher.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	secondDone := make(chan error, 1)
+	go func() {
+		_, slot, err := cache.GetConfig(context.Background(), config)
+		if err == nil && slot != 42 {
+			err = errors.New("unexpected slot")
+		}
+		secondDone <- err
+	}()
+	for cache.Stats().Deduplicated == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancelFirst()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the first caller to give up on its own context, got %v", err)
+	}
+
+	close(fetcher.release)
+	if err := <-secondDone; err != nil {
+		t.Fatalf("expected the second caller to get the shared result, got %v", err)
+	}
+	if calls := fetcher.calls.Load(); calls != 1 {
+		t.Fatalf("expected one shared request, got %d", calls)
+	}
+	if stats := cache.Stats(); stats.Entries != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,206 @@
+package damm
+
+import (
+	"context"
+	"time"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/dannwee/dbc-go/instructions"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Logger receives debug output from the client
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Printf(string, ...interface{}) {}
+
+// Client reads cp_amm accounts through an AccountFetcher. Its methods wrap the functions in
+// instructions with the client's options and logging, both stay supported.
+type Client struct {
+	fetcher common.AccountFetcher
+	options []common.FetchOption
+	logger  Logger
+}
+
+type Option func(*Client)
+
+// WithCommitment sets the commitment level of every request
+func WithCommitment(commitment rpc.CommitmentType) Option {
+	return func(c *Client) {
+		c.options = append(c.options, common.WithCommitment(commitment))
+	}
+}
+
+// WithProgramID overrides the cp_amm program ID, e.g. for a devnet deployment
+func WithProgramID(programID solana.PublicKey) Option {
+	return func(c *Client) {
+		c.options = append(c.options, common.WithProgramID(programID))
+	}
+}
+
+// WithBatchConcurrency sets how many getMultipleAccounts requests run at once
+func WithBatchConcurrency(concurrency int) Option {
+	return func(c *Client) {
+		c.options = append(c.options, common.WithBatchConcurrency(concurrency))
+	}
+}
+
+// WithZstdEncoding requests account data as base64+zstd
+func WithZstdEncoding() Option {
+	return func(c *Client) {
+		c.options = append(c.options, common.WithZstdEncoding())
+	}
+}
+
+// WithLogger logs every request the client makes
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// NewClient creates a client reading through fetcher, which is usually an *rpc.Client
+func NewClient(fetcher common.AccountFetcher, opts ...Option) *Client {
+	c := &Client{
+		fetcher: fetcher,
+		logger:  nopLogger{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewClientFromURL creates a client for an RPC endpoint
+func NewClientFromURL(endpoint string, opts ...Option) *Client {
+	return NewClient(rpc.New(endpoint), opts...)
+}
+
+// Fetcher returns the AccountFetcher the client reads through
+func (c *Client) Fetcher() common.AccountFetcher {
+	return c.fetcher
+}
+
+// fetchOptions returns the client's fetch options followed by per call overrides
+func (c *Client) fetchOptions(opts []common.FetchOption) []common.FetchOption {
+	return append(append([]common.FetchOption(nil), c.options...), opts...)
+}
+
+// logDone logs the duration and outcome of a call, deferred with a pointer to its named error
+func (c *Client) logDone(method string, start time.Time, err *error) {
+	if *err != nil {
+		c.logger.Printf("damm: %s failed after %s: %v", method, time.Since(start), *err)
+		return
+	}
+	c.logger.Printf("damm: %s took %s", method, time.Since(start))
+}
+
+func (c *Client) GetPool(ctx context.Context, poolAddress solana.PublicKey, opts ...common.FetchOption) (pool *common.Pool, err error) {
+	defer c.logDone("GetPool", time.Now(), &err)
+	return instructions.GetPool(ctx, poolAddress, c.fetcher, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPoolWithSlot(ctx context.Context, poolAddress solana.PublicKey, opts ...common.FetchOption) (pool *common.Pool, slot uint64, err error) {
+	defer c.logDone("GetPoolWithSlot", time.Now(), &err)
+	return instructions.GetPoolWithSlot(ctx, poolAddress, c.fetcher, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPools(ctx context.Context, poolAddresses []solana.PublicKey, opts ...common.FetchOption) (pools []*common.Pool, err error) {
+	defer c.logDone("GetPools", time.Now(), &err)
+	return instructions.GetPools(ctx, poolAddresses, c.fetcher, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPoolInfo(ctx context.Context, poolAddress solana.PublicKey, opts ...common.FetchOption) (info *common.PoolInfo, err error) {
+	defer c.logDone("GetPoolInfo", time.Now(), &err)
+	return instructions.GetPoolInfo(ctx, poolAddress, c.fetcher, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPoolInfoFromPool(ctx context.Context, poolAddress solana.PublicKey, pool *common.Pool, opts ...common.FetchOption) (info *common.PoolInfo, err error) {
+	defer c.logDone("GetPoolInfoFromPool", time.Now(), &err)
+	return instructions.GetPoolInfoFromPool(ctx, poolAddress, pool, c.fetcher, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetVaultBalances(ctx context.Context, pools []*common.Pool, opts ...common.FetchOption) (balances []common.TokenAmounts, err error) {
+	defer c.logDone("GetVaultBalances", time.Now(), &err)
+	return instructions.GetVaultBalances(ctx, pools, c.fetcher, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPosition(ctx context.Context, positionAddress solana.PublicKey, opts ...common.FetchOption) (position *common.PositionState, err error) {
+	defer c.logDone("GetPosition", time.Now(), &err)
+	return instructions.GetPosition(ctx, positionAddress, c.fetcher, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPositionWithSlot(ctx context.Context, positionAddress solana.PublicKey, opts ...common.FetchOption) (position *common.PositionState, slot uint64, err error) {
+	defer c.logDone("GetPositionWithSlot", time.Now(), &err)
+	return instructions.GetPositionWithSlot(ctx, positionAddress, c.fetcher, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPositionsByUser(ctx context.Context, user solana.PublicKey, opts ...common.FetchOption) (positions []common.PositionResult, err error) {
+	defer c.logDone("GetPositionsByUser", time.Now(), &err)
+	return instructions.GetPositionsByUser(ctx, c.fetcher, user, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetUserPositionByPool(ctx context.Context, pool solana.PublicKey, user solana.PublicKey, opts ...common.FetchOption) (positions []common.PositionResult, err error) {
+	defer c.logDone("GetUserPositionByPool", time.Now(), &err)
+	return instructions.GetUserPositionByPool(ctx, c.fetcher, pool, user, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetAllPositionNftAccountByOwner(ctx context.Context, user solana.PublicKey, opts ...common.FetchOption) (accounts []common.PositionNftAccount, err error) {
+	defer c.logDone("GetAllPositionNftAccountByOwner", time.Now(), &err)
+	return common.GetAllPositionNftAccountByOwner(ctx, c.fetcher, user, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetAllPositions(ctx context.Context, opts ...common.FetchOption) (positions []common.PositionResult, err error) {
+	defer c.logDone("GetAllPositions", time.Now(), &err)
+	return instructions.GetAllPositions(ctx, c.fetcher, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetAllPositionsByPool(ctx context.Context, pool solana.PublicKey, pageOpts *instructions.GetAllPositionsByPoolOpts, opts ...common.FetchOption) (positions []common.PositionResult, err error) {
+	defer c.logDone("GetAllPositionsByPool", time.Now(), &err)
+	return instructions.GetAllPositionsByPool(ctx, pool, c.fetcher, pageOpts, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPoolsByMint(ctx context.Context, mint solana.PublicKey, sortBy common.PoolSortBy, opts ...common.FetchOption) (pools []common.PoolResult, err error) {
+	defer c.logDone("GetPoolsByMint", time.Now(), &err)
+	return instructions.GetPoolsByMint(ctx, mint, c.fetcher, sortBy, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPoolsByPair(ctx context.Context, mintX solana.PublicKey, mintY solana.PublicKey, sortBy common.PoolSortBy, opts ...common.FetchOption) (pools []common.PoolResult, err error) {
+	defer c.logDone("GetPoolsByPair", time.Now(), &err)
+	return instructions.GetPoolsByPair(ctx, mintX, mintY, c.fetcher, sortBy, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPoolsByPartner(ctx context.Context, partner solana.PublicKey, sortBy common.PoolSortBy, opts ...common.FetchOption) (pools []common.PoolResult, err error) {
+	defer c.logDone("GetPoolsByPartner", time.Now(), &err)
+	return instructions.GetPoolsByPartner(ctx, partner, c.fetcher, sortBy, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPoolsByConfig(ctx context.Context, config solana.PublicKey, sortBy common.PoolSortBy, opts ...common.FetchOption) (pools []common.PoolResult, err error) {
+	defer c.logDone("GetPoolsByConfig", time.Now(), &err)
+	return instructions.GetPoolsByConfig(ctx, config, c.fetcher, sortBy, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetConfig(ctx context.Context, configAddress solana.PublicKey, opts ...common.FetchOption) (config *common.Config, err error) {
+	defer c.logDone("GetConfig", time.Now(), &err)
+	return instructions.GetConfig(ctx, configAddress, c.fetcher, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetConfigWithSlot(ctx context.Context, configAddress solana.PublicKey, opts ...common.FetchOption) (config *common.Config, slot uint64, err error) {
+	defer c.logDone("GetConfigWithSlot", time.Now(), &err)
+	return instructions.GetConfigWithSlot(ctx, configAddress, c.fetcher, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPositionOwner(ctx context.Context, positionAddress solana.PublicKey, opts ...common.FetchOption) (owner *common.PositionOwner, err error) {
+	defer c.logDone("GetPositionOwner", time.Now(), &err)
+	return instructions.GetPositionOwner(ctx, positionAddress, c.fetcher, c.fetchOptions(opts)...)
+}
+
+func (c *Client) GetPositionOwnerByNft(ctx context.Context, positionNft solana.PublicKey, opts ...common.FetchOption) (owner *common.PositionOwner, err error) {
+	defer c.logDone("GetPositionOwnerByNft", time.Now(), &err)
+	return instructions.GetPositionOwnerByNft(ctx, positionNft, c.fetcher, c.fetchOptions(opts)...)
+}
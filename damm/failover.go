@@ -0,0 +1,234 @@
+package damm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Endpoint is one RPC backend of a FailoverFetcher
+type Endpoint struct {
+	Name    string
+	Fetcher common.AccountFetcher
+}
+
+// EndpointStats is the health of one endpoint as seen by a FailoverFetcher
+type EndpointStats struct {
+	Name                string
+	Requests            uint64
+	Failures            uint64
+	ConsecutiveFailures int
+	LastError           string
+	LastErrorAt         time.Time
+	LastLatency         time.Duration
+	AverageLatency      time.Duration
+	Healthy             bool
+}
+
+type endpointState struct {
+	Endpoint
+	requests            uint64
+	failures            uint64
+	consecutiveFailures int
+	lastError           string
+	lastErrorAt         time.Time
+	lastLatency         time.Duration
+	totalLatency        time.Duration
+}
+
+// FailoverFetcher sends each request to the first healthy endpoint and moves on to the next one
+// when it fails with a retryable error. An endpoint that fails FailureThreshold times in a row
+// is skipped for Cooldown, unless every endpoint is unhealthy.
+type FailoverFetcher struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	mu        sync.Mutex
+	endpoints []*endpointState
+}
+
+// NewFailoverFetcher creates a fetcher over endpoints, in order of preference
+func NewFailoverFetcher(endpoints []Endpoint) *FailoverFetcher {
+	f := &FailoverFetcher{
+		FailureThreshold: 3,
+		Cooldown:         30 * time.Second,
+	}
+	for _, endpoint := range endpoints {
+		f.endpoints = append(f.endpoints, &endpointState{Endpoint: endpoint})
+	}
+	return f
+}
+
+// healthy reports whether the endpoint is outside its cooldown, with f.mu held
+func (f *FailoverFetcher) healthy(e *endpointState, now time.Time) bool {
+	return e.consecutiveFailures < f.FailureThreshold || now.Sub(e.lastErrorAt) >= f.Cooldown
+}
+
+// order returns the healthy endpoints followed by the unhealthy ones
+func (f *FailoverFetcher) order() []*endpointState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	healthy := make([]*endpointState, 0, len(f.endpoints))
+	var unhealthy []*endpointState
+	for _, e := range f.endpoints {
+		if f.healthy(e, now) {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// record updates the endpoint stats after a request
+func (f *FailoverFetcher) record(e *endpointState, latency time.Duration, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e.requests++
+	e.lastLatency = latency
+	e.totalLatency += latency
+	if IsRetryable(err) {
+		e.failures++
+		e.consecutiveFailures++
+		e.lastError = err.Error()
+		e.lastErrorAt = time.Now()
+		return
+	}
+	e.consecutiveFailures = 0
+}
+
+// do runs fn against each endpoint in turn until one succeeds or fails with a non retryable error
+func (f *FailoverFetcher) do(ctx context.Context, fn func(common.AccountFetcher) error) error {
+	endpoints := f.order()
+	if len(endpoints) == 0 {
+		return fmt.Errorf("no RPC endpoints configured")
+	}
+
+	var err error
+	for _, e := range endpoints {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		start := time.Now()
+		err = fn(e.Fetcher)
+		if ctx.Err() == nil {
+			f.record(e, time.Since(start), err)
+		}
+		if !IsRetryable(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("all %d RPC endpoints failed: %w", len(endpoints), err)
+}
+
+// Stats returns the health of every endpoint, in order of preference
+func (f *FailoverFetcher) Stats() []EndpointStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]EndpointStats, 0, len(f.endpoints))
+	for _, e := range f.endpoints {
+		var average time.Duration
+		if e.requests > 0 {
+			average = e.totalLatency / time.Duration(e.requests)
+		}
+		stats = append(stats, EndpointStats{
+			Name:                e.Name,
+			Requests:            e.requests,
+			Failures:            e.failures,
+			ConsecutiveFailures: e.consecutiveFailures,
+			LastError:           e.lastError,
+			LastErrorAt:         e.lastErrorAt,
+			LastLatency:         e.lastLatency,
+			AverageLatency:      average,
+			Healthy:             f.healthy(e, now),
+		})
+	}
+	return stats
+}
+
+func (f *FailoverFetcher) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey, opts *rpc.GetAccountInfoOpts) (out *rpc.GetAccountInfoResult, err error) {
+	err = f.do(ctx, func(next common.AccountFetcher) (err error) {
+		out, err = next.GetAccountInfoWithOpts(ctx, account, opts)
+		return err
+	})
+	return out, err
+}
+
+func (f *FailoverFetcher) GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey, opts *rpc.GetMultipleAccountsOpts) (out *rpc.GetMultipleAccountsResult, err error) {
+	err = f.do(ctx, func(next common.AccountFetcher) (err error) {
+		out, err = next.GetMultipleAccountsWithOpts(ctx, accounts, opts)
+		return err
+	})
+	return out, err
+}
+
+func (f *FailoverFetcher) GetProgramAccountsWithOpts(ctx context.Context, program solana.PublicKey, opts *rpc.GetProgramAccountsOpts) (out rpc.GetProgramAccountsResult, err error) {
+	err = f.do(ctx, func(next common.AccountFetcher) (err error) {
+		out, err = next.GetProgramAccountsWithOpts(ctx, program, opts)
+		return err
+	})
+	return out, err
+}
+
+func (f *FailoverFetcher) GetTokenAccountsByOwner(ctx context.Context, owner solana.PublicKey, conf *rpc.GetTokenAccountsConfig, opts *rpc.GetTokenAccountsOpts) (out *rpc.GetTokenAccountsResult, err error) {
+	err = f.do(ctx, func(next common.AccountFetcher) (err error) {
+		out, err = next.GetTokenAccountsByOwner(ctx, owner, conf, opts)
+		return err
+	})
+	return out, err
+}
+
+func (f *FailoverFetcher) GetTokenLargestAccounts(ctx context.Context, mint solana.PublicKey, commitment rpc.CommitmentType) (out *rpc.GetTokenLargestAccountsResult, err error) {
+	err = f.do(ctx, func(next common.AccountFetcher) (err error) {
+		out, err = next.GetTokenLargestAccounts(ctx, mint, commitment)
+		return err
+	})
+	return out, err
+}
+
+// ResilientOptions configure NewResilientFetcher
+type ResilientOptions struct {
+	// RequestsPerSecond is the request budget of each endpoint, 0 for no limit
+	RequestsPerSecond int
+	Retry             RetryPolicy
+}
+
+// ResilientFetcher retries requests across several rate limited RPC endpoints
+type ResilientFetcher struct {
+	common.AccountFetcher
+	failover *FailoverFetcher
+}
+
+// NewResilientFetcher stacks the middlewares for a list of RPC URLs: each endpoint gets its own
+// rate limit, requests fail over between endpoints, and failed rounds are retried with backoff
+func NewResilientFetcher(urls []string, options ResilientOptions) *ResilientFetcher {
+	endpoints := make([]Endpoint, 0, len(urls))
+	for _, url := range urls {
+		endpoints = append(endpoints, Endpoint{
+			Name:    url,
+			Fetcher: NewRateLimitedFetcher(rpc.New(url), options.RequestsPerSecond),
+		})
+	}
+
+	failover := NewFailoverFetcher(endpoints)
+	return &ResilientFetcher{
+		AccountFetcher: NewRetryFetcher(failover, options.Retry),
+		failover:       failover,
+	}
+}
+
+// Stats returns the health of every endpoint
+func (f *ResilientFetcher) Stats() []EndpointStats {
+	return f.failover.Stats()
+}
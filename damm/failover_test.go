@@ -0,0 +1,79 @@
+package damm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestFailoverFetcherMovesToNextEndpoint(t *testing.T) {
+	servers := []*scriptedServer{
+		newScriptedServer(t, replyRateLimited),
+		newScriptedServer(t, replyUnavailable),
+		newScriptedServer(t, replyConnectionReset),
+		newScriptedServer(t),
+	}
+	endpoints := make([]Endpoint, len(servers))
+	for i, server := range servers {
+		endpoints[i] = Endpoint{Name: server.URL, Fetcher: rpc.New(server.URL)}
+	}
+
+	fetcher := NewFailoverFetcher(endpoints)
+	fetcher.FailureThreshold = 1
+	fetcher.Cooldown = time.Hour
+
+	if _, err := fetcher.GetMultipleAccountsWithOpts(context.Background(), []solana.PublicKey{{}}, nil); err != nil {
+		t.Fatalf("expected the last endpoint to succeed, got %v", err)
+	}
+	for i, server := range servers {
+		if hits := server.Hits(); hits != 1 {
+			t.Fatalf("expected endpoint %d to be hit once, got %d", i, hits)
+		}
+	}
+
+	// The failed endpoints are cooling down, so the next request goes straight to the healthy one
+	if _, err := fetcher.GetMultipleAccountsWithOpts(context.Background(), []solana.PublicKey{{}}, nil); err != nil {
+		t.Fatalf("expected the healthy endpoint to succeed, got %v", err)
+	}
+	for i, want := range []int{1, 1, 1, 2} {
+		if hits := servers[i].Hits(); hits != want {
+			t.Fatalf("expected endpoint %d to be hit %d times, got %d", i, want, hits)
+		}
+	}
+
+	stats := fetcher.Stats()
+	for i, want := range []bool{false, false, false, true} {
+		if stats[i].Healthy != want {
+			t.Fatalf("expected endpoint %d healthy=%v, got %+v", i, want, stats[i])
+		}
+	}
+	if stats[3].Failures != 0 || stats[3].Requests != 2 {
+		t.Fatalf("unexpected stats for the healthy endpoint: %+v", stats[3])
+	}
+}
+
+func TestFailoverFetcherReturnsLastErrorWhenAllFail(t *testing.T) {
+	servers := []*scriptedServer{
+		newScriptedServer(t, replyRateLimited),
+		newScriptedServer(t, replyUnavailable),
+		newScriptedServer(t, replyConnectionReset),
+	}
+	endpoints := make([]Endpoint, len(servers))
+	for i, server := range servers {
+		endpoints[i] = Endpoint{Name: server.URL, Fetcher: rpc.New(server.URL)}
+	}
+
+	fetcher := NewFailoverFetcher(endpoints)
+	_, err := fetcher.GetMultipleAccountsWithOpts(context.Background(), []solana.PublicKey{{}}, nil)
+	if !IsRetryable(err) {
+		t.Fatalf("expected a retryable error once every endpoint failed, got %v", err)
+	}
+	for i, server := range servers {
+		if hits := server.Hits(); hits != 1 {
+			t.Fatalf("expected endpoint %d to be hit once, got %d", i, hits)
+		}
+	}
+}
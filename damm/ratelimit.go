@@ -0,0 +1,66 @@
+package damm
+
+import (
+	"context"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitedFetcher waits for the limiter before every request
+type rateLimitedFetcher struct {
+	next    common.AccountFetcher
+	limiter *rate.Limiter
+}
+
+// NewRateLimitedFetcher spaces the requests of next so at most requestsPerSecond are sent each second.
+// A non positive rate disables the limit.
+func NewRateLimitedFetcher(next common.AccountFetcher, requestsPerSecond int) common.AccountFetcher {
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if requestsPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), 1)
+	}
+	return &rateLimitedFetcher{next: next, limiter: limiter}
+}
+
+// wait blocks until the limiter allows the next request or ctx is done
+func (f *rateLimitedFetcher) wait(ctx context.Context) error {
+	return f.limiter.Wait(ctx)
+}
+
+func (f *rateLimitedFetcher) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey, opts *rpc.GetAccountInfoOpts) (*rpc.GetAccountInfoResult, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return f.next.GetAccountInfoWithOpts(ctx, account, opts)
+}
+
+func (f *rateLimitedFetcher) GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey, opts *rpc.GetMultipleAccountsOpts) (*rpc.GetMultipleAccountsResult, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return f.next.GetMultipleAccountsWithOpts(ctx, accounts, opts)
+}
+
+func (f *rateLimitedFetcher) GetProgramAccountsWithOpts(ctx context.Context, program solana.PublicKey, opts *rpc.GetProgramAccountsOpts) (rpc.GetProgramAccountsResult, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return f.next.GetProgramAccountsWithOpts(ctx, program, opts)
+}
+
+func (f *rateLimitedFetcher) GetTokenAccountsByOwner(ctx context.Context, owner solana.PublicKey, conf *rpc.GetTokenAccountsConfig, opts *rpc.GetTokenAccountsOpts) (*rpc.GetTokenAccountsResult, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return f.next.GetTokenAccountsByOwner(ctx, owner, conf, opts)
+}
+
+func (f *rateLimitedFetcher) GetTokenLargestAccounts(ctx context.Context, mint solana.PublicKey, commitment rpc.CommitmentType) (*rpc.GetTokenLargestAccountsResult, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return f.next.GetTokenLargestAccounts(ctx, mint, commitment)
+}
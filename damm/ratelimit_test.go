@@ -0,0 +1,53 @@
+package damm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestRateLimitedFetcherSpacesRetries(t *testing.T) {
+	server := newScriptedServer(t, replyRateLimited, replyUnavailable, replyConnectionReset)
+	policy := RetryPolicy{MaxAttempts: 4}
+	fetcher := NewRetryFetcher(NewRateLimitedFetcher(rpc.New(server.URL), 20), policy)
+
+	start := time.Now()
+	if _, err := fetcher.GetMultipleAccountsWithOpts(context.Background(), []solana.PublicKey{{}}, nil); err != nil {
+		t.Fatalf("expected the fourth attempt to succeed, got %v", err)
+	}
+	if hits := server.Hits(); hits != 4 {
+		t.Fatalf("expected 4 attempts, got %d", hits)
+	}
+	// At 20 requests per second the three retries wait 50ms each
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Fatalf("expected the attempts to be spaced by the limiter, took %s", elapsed)
+	}
+}
+
+func TestRateLimitedFetcherStopsWaitingWhenContextIsDone(t *testing.T) {
+	server := newScriptedServer(t, replyRateLimited, replyUnavailable, replyConnectionReset)
+	fetcher := NewRateLimitedFetcher(rpc.New(server.URL), 1)
+
+	if _, err := fetcher.GetMultipleAccountsWithOpts(context.Background(), []solana.PublicKey{{}}, nil); err == nil {
+		t.Fatal("expected the rate limited error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := fetcher.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{{}}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the wait to be canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the wait to end with the context, took %s", elapsed)
+	}
+	if hits := server.Hits(); hits != 1 {
+		t.Fatalf("expected the canceled request not to be sent, got %d requests", hits)
+	}
+}
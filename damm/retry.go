@@ -0,0 +1,145 @@
+package damm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// RetryPolicy controls how many times a failed request is retried and how long to wait in between
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy retries up to 3 times with delays starting at 250ms and capped at 5s
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying:
+// rate limiting, server errors, lagging nodes and network errors
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var httpErr *jsonrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code == 429 || httpErr.Code >= 500
+	}
+
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case 429, -32004, -32005, -32007, -32014, -32603:
+			// Rate limited, block not available, node behind, slot skipped, internal error
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// backoff returns the delay before the given retry, doubling each time with up to 50% jitter
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// do runs fn until it succeeds, fails with a non retryable error, or runs out of attempts
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 && !sleepContext(ctx, p.backoff(attempt-1)) {
+			return err
+		}
+
+		err = fn()
+		if !IsRetryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// retryFetcher retries requests that fail with a retryable error
+type retryFetcher struct {
+	next   common.AccountFetcher
+	policy RetryPolicy
+}
+
+// NewRetryFetcher retries the requests of next that fail with a retryable error, backing off between attempts
+func NewRetryFetcher(next common.AccountFetcher, policy RetryPolicy) common.AccountFetcher {
+	return &retryFetcher{next: next, policy: policy}
+}
+
+func (f *retryFetcher) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey, opts *rpc.GetAccountInfoOpts) (out *rpc.GetAccountInfoResult, err error) {
+	err = f.policy.do(ctx, func() (err error) {
+		out, err = f.next.GetAccountInfoWithOpts(ctx, account, opts)
+		return err
+	})
+	return out, err
+}
+
+func (f *retryFetcher) GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey, opts *rpc.GetMultipleAccountsOpts) (out *rpc.GetMultipleAccountsResult, err error) {
+	err = f.policy.do(ctx, func() (err error) {
+		out, err = f.next.GetMultipleAccountsWithOpts(ctx, accounts, opts)
+		return err
+	})
+	return out, err
+}
+
+func (f *retryFetcher) GetProgramAccountsWithOpts(ctx context.Context, program solana.PublicKey, opts *rpc.GetProgramAccountsOpts) (out rpc.GetProgramAccountsResult, err error) {
+	err = f.policy.do(ctx, func() (err error) {
+		out, err = f.next.GetProgramAccountsWithOpts(ctx, program, opts)
+		return err
+	})
+	return out, err
+}
+
+func (f *retryFetcher) GetTokenAccountsByOwner(ctx context.Context, owner solana.PublicKey, conf *rpc.GetTokenAccountsConfig, opts *rpc.GetTokenAccountsOpts) (out *rpc.GetTokenAccountsResult, err error) {
+	err = f.policy.do(ctx, func() (err error) {
+		out, err = f.next.GetTokenAccountsByOwner(ctx, owner, conf, opts)
+		return err
+	})
+	return out, err
+}
+
+func (f *retryFetcher) GetTokenLargestAccounts(ctx context.Context, mint solana.PublicKey, commitment rpc.CommitmentType) (out *rpc.GetTokenLargestAccountsResult, err error) {
+	err = f.policy.do(ctx, func() (err error) {
+		out, err = f.next.GetTokenLargestAccounts(ctx, mint, commitment)
+		return err
+	})
+	return out, err
+}
@@ -0,0 +1,63 @@
+package damm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+var testPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    5 * time.Millisecond,
+}
+
+func TestRetryFetcherRetriesTransientFailures(t *testing.T) {
+	server := newScriptedServer(t, replyRateLimited, replyUnavailable, replyConnectionReset)
+	fetcher := NewRetryFetcher(rpc.New(server.URL), testPolicy)
+
+	_, err := fetcher.GetMultipleAccountsWithOpts(context.Background(), []solana.PublicKey{{}}, nil)
+	if err != nil {
+		t.Fatalf("expected the fourth attempt to succeed, got %v", err)
+	}
+	if hits := server.Hits(); hits != 4 {
+		t.Fatalf("expected 4 attempts, got %d", hits)
+	}
+}
+
+func TestRetryFetcherGivesUpAfterMaxAttempts(t *testing.T) {
+	server := newScriptedServer(t, replyRateLimited, replyUnavailable, replyConnectionReset, replyRateLimited)
+	policy := testPolicy
+	policy.MaxAttempts = 3
+	fetcher := NewRetryFetcher(rpc.New(server.URL), policy)
+
+	_, err := fetcher.GetMultipleAccountsWithOpts(context.Background(), []solana.PublicKey{{}}, nil)
+	if !IsRetryable(err) {
+		t.Fatalf("expected the connection reset of the last attempt, got %v", err)
+	}
+	if hits := server.Hits(); hits != 3 {
+		t.Fatalf("expected 3 attempts, got %d", hits)
+	}
+}
+
+func TestRetryFetcherStopsWhenContextIsDone(t *testing.T) {
+	server := newScriptedServer(t, replyRateLimited, replyUnavailable, replyConnectionReset)
+	policy := testPolicy
+	policy.BaseDelay = time.Hour
+	policy.MaxDelay = time.Hour
+	fetcher := NewRetryFetcher(rpc.New(server.URL), policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := fetcher.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{{}}, nil)
+	if err == nil {
+		t.Fatal("expected the rate limited error")
+	}
+	if hits := server.Hits(); hits != 1 {
+		t.Fatalf("expected 1 attempt before the backoff outlived the context, got %d", hits)
+	}
+}
@@ -0,0 +1,83 @@
+package damm
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// Scripted failures a test server answers with, in order. Once the script runs out it answers successfully.
+const (
+	replyRateLimited     = "429"
+	replyUnavailable     = "503"
+	replyConnectionReset = "reset"
+)
+
+// scriptedServer is an RPC node answering getMultipleAccounts with a list of failures before succeeding
+type scriptedServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	script []string
+	hits   int
+}
+
+func newScriptedServer(t *testing.T, script ...string) *scriptedServer {
+	t.Helper()
+
+	s := &scriptedServer{script: script}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Hits returns how many requests the server received
+func (s *scriptedServer) Hits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits
+}
+
+func (s *scriptedServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.hits++
+	reply := ""
+	if len(s.script) > 0 {
+		reply, s.script = s.script[0], s.script[1:]
+	}
+	s.mu.Unlock()
+
+	switch reply {
+	case replyRateLimited:
+		http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+	case replyUnavailable:
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	case replyConnectionReset:
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		// Closing with a zero linger sends a RST instead of a FIN
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			_ = tcp.SetLinger(0)
+		}
+		_ = conn.Close()
+	default:
+		var request struct {
+			ID any `json:"id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      request.ID,
+			"result": map[string]any{
+				"context": map[string]any{"slot": 1},
+				"value":   []any{nil},
+			},
+		})
+	}
+}
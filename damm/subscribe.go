@@ -0,0 +1,224 @@
+package damm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/dannwee/dbc-go/instructions"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+// Subscriber streams decoded account updates over a websocket endpoint.
+// Every subscription holds its own connection and reconnects with backoff until its context is done.
+type Subscriber struct {
+	client   *Client
+	endpoint string
+
+	// BufferSize is the capacity of the update channels
+	BufferSize int
+	// Reconnect controls the delay between reconnection attempts, MaxAttempts is ignored
+	Reconnect RetryPolicy
+}
+
+// NewSubscriber creates a subscriber for a websocket endpoint such as wss://api.mainnet-beta.solana.com.
+// The client sets the commitment and program ID and is used to resync after a reconnect.
+func NewSubscriber(client *Client, wsEndpoint string) *Subscriber {
+	return &Subscriber{
+		client:     client,
+		endpoint:   wsEndpoint,
+		BufferSize: 16,
+		Reconnect:  RetryPolicy{BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second},
+	}
+}
+
+// SubscribePool streams the pool's state every time it changes, starting with its current state.
+// The channel is closed when ctx is done.
+func (s *Subscriber) SubscribePool(ctx context.Context, poolAddress solana.PublicKey) (<-chan common.PoolUpdate, error) {
+	conn, err := ws.Connect(ctx, s.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect websocket: %w", err)
+	}
+
+	updates := make(chan common.PoolUpdate, s.BufferSize)
+	go func() {
+		defer close(updates)
+		s.streamAccount(ctx, conn, poolAddress, func(account *rpc.Account, slot uint64, programID solana.PublicKey) {
+			pool, err := instructions.DecodePool(account, programID)
+			if err != nil {
+				s.client.logger.Printf("damm: failed to decode pool %s: %v", poolAddress, err)
+				return
+			}
+			select {
+			case updates <- common.PoolUpdate{Pool: poolAddress, PoolState: *pool, Slot: slot}:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	return updates, nil
+}
+
+// SubscribePosition streams the position's state every time it changes, starting with its current state.
+// The channel is closed when ctx is done.
+func (s *Subscriber) SubscribePosition(ctx context.Context, positionAddress solana.PublicKey) (<-chan common.PositionUpdate, error) {
+	conn, err := ws.Connect(ctx, s.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect websocket: %w", err)
+	}
+
+	updates := make(chan common.PositionUpdate, s.BufferSize)
+	go func() {
+		defer close(updates)
+		s.streamAccount(ctx, conn, positionAddress, func(account *rpc.Account, slot uint64, programID solana.PublicKey) {
+			position, err := instructions.DecodePosition(account, programID)
+			if err != nil {
+				s.client.logger.Printf("damm: failed to decode position %s: %v", positionAddress, err)
+				return
+			}
+			select {
+			case updates <- common.PositionUpdate{Position: positionAddress, PositionState: *position, Slot: slot}:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	return updates, nil
+}
+
+// SubscribePools streams every pool of the program as it changes.
+// Unlike SubscribePool there is no resync, changes made while reconnecting are not replayed.
+func (s *Subscriber) SubscribePools(ctx context.Context) (<-chan common.PoolUpdate, error) {
+	conn, err := ws.Connect(ctx, s.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect websocket: %w", err)
+	}
+
+	options := common.NewFetchOptions(s.client.fetchOptions(nil)...)
+	filters := []rpc.RPCFilter{
+		{DataSize: common.PoolAccountSize},
+		{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: common.PoolDiscriminator}},
+	}
+
+	updates := make(chan common.PoolUpdate, s.BufferSize)
+	go func() {
+		defer close(updates)
+		s.reconnectLoop(ctx, conn, func(conn *ws.Client) (bool, error) {
+			sub, err := conn.ProgramSubscribeWithOpts(options.ProgramID, options.Commitment, options.Encoding, filters)
+			if err != nil {
+				return false, fmt.Errorf("failed to subscribe to program: %w", err)
+			}
+			defer sub.Unsubscribe()
+
+			for {
+				result, err := sub.Recv(ctx)
+				if err != nil {
+					return true, err
+				}
+				pool, err := instructions.DecodePool(result.Value.Account, options.ProgramID)
+				if err != nil {
+					s.client.logger.Printf("damm: failed to decode pool %s: %v", result.Value.Pubkey, err)
+					continue
+				}
+				select {
+				case updates <- common.PoolUpdate{Pool: result.Value.Pubkey, PoolState: *pool, Slot: result.Context.Slot}:
+				case <-ctx.Done():
+					return true, ctx.Err()
+				}
+			}
+		})
+	}()
+
+	return updates, nil
+}
+
+// streamAccount runs accountSubscribe on address until ctx is done. After every (re)subscribe the account is
+// read with getAccountInfo so updates missed while disconnected are not lost. Updates older than one already
+// delivered are dropped.
+func (s *Subscriber) streamAccount(ctx context.Context, conn *ws.Client, address solana.PublicKey, onUpdate func(account *rpc.Account, slot uint64, programID solana.PublicKey)) {
+	options := common.NewFetchOptions(s.client.fetchOptions(nil)...)
+
+	var lastSlot uint64
+	deliver := func(account *rpc.Account, slot uint64) {
+		if slot < lastSlot {
+			return
+		}
+		lastSlot = slot
+		onUpdate(account, slot, options.ProgramID)
+	}
+
+	s.reconnectLoop(ctx, conn, func(conn *ws.Client) (bool, error) {
+		sub, err := conn.AccountSubscribeWithOpts(address, options.Commitment, options.Encoding)
+		if err != nil {
+			return false, fmt.Errorf("failed to subscribe to account: %w", err)
+		}
+		defer sub.Unsubscribe()
+
+		// Subscribe first, then read, so no change falls in between
+		account, err := s.client.fetcher.GetAccountInfoWithOpts(ctx, address, options.AccountInfoOpts())
+		if err != nil {
+			s.client.logger.Printf("damm: failed to resync %s: %v", address, err)
+		} else if account != nil && account.Value != nil {
+			deliver(account.Value, account.Context.Slot)
+		}
+
+		for {
+			result, err := sub.Recv(ctx)
+			if err != nil {
+				return true, err
+			}
+			deliver(&result.Value.Account, result.Context.Slot)
+		}
+	})
+}
+
+// reconnectLoop runs session on conn, reconnecting whenever it ends, until ctx is done.
+// session reports whether it subscribed successfully, which resets the backoff.
+func (s *Subscriber) reconnectLoop(ctx context.Context, conn *ws.Client, session func(conn *ws.Client) (bool, error)) {
+	retry := 0
+	for {
+		if conn == nil {
+			var err error
+			conn, err = ws.Connect(ctx, s.endpoint)
+			if err != nil {
+				s.client.logger.Printf("damm: failed to reconnect websocket: %v", err)
+				if !sleepContext(ctx, s.Reconnect.backoff(retry)) {
+					return
+				}
+				retry++
+				continue
+			}
+		}
+
+		subscribed, err := session(conn)
+		conn.Close()
+		conn = nil
+		if ctx.Err() != nil {
+			return
+		}
+		if subscribed {
+			retry = 0
+		}
+
+		s.client.logger.Printf("damm: websocket subscription ended, reconnecting: %v", err)
+		if !sleepContext(ctx, s.Reconnect.backoff(retry)) {
+			return
+		}
+		retry++
+	}
+}
+
+// sleepContext waits for d, returning false if ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
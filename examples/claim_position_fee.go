@@ -2,16 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"time"
 
 	"github.com/gagliardetto/solana-go"
-	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
 	"github.com/gagliardetto/solana-go/rpc"
 
 	"github.com/dannwee/dbc-go/helpers"
 	"github.com/dannwee/dbc-go/instructions"
+	"github.com/dannwee/dbc-go/transaction"
 )
 
 func ClaimPositionFee() {
@@ -25,11 +25,14 @@ func ClaimPositionFee() {
 	// 2) pool address
 	poolAddress := solana.MustPublicKeyFromBase58("YOUR_POOL_ADDRESS")
 
-	// 3) get pool state
-	poolState, err := instructions.GetPool(ctx, poolAddress, client)
+	// 3) get pool state, vault balances and mint details
+	poolInfo, err := instructions.GetPoolInfo(ctx, poolAddress, client)
 	if err != nil {
-		log.Fatalf("Failed to get pool state: %v", err)
+		log.Fatalf("Failed to get pool info: %v", err)
 	}
+	poolState := &poolInfo.PoolState
+	tokenAProgram := poolInfo.TokenAMint.TokenProgram
+	tokenBProgram := poolInfo.TokenBMint.TokenProgram
 
 	// 4) get user positions for this pool
 	positions, err := instructions.GetUserPositionByPool(ctx, client, poolAddress, userWallet)
@@ -48,7 +51,7 @@ func ClaimPositionFee() {
 		log.Fatalf("Failed to get position state: %v", err)
 	}
 
-	// 6) get unclaimed rewards (TODO: check math for this)
+	// 6) get unclaimed rewards
 	unclaimedReward, err := helpers.GetUnclaimReward(poolState, positionState)
 	if err != nil {
 		log.Fatalf("Failed to get unclaimed rewards: %v", err)
@@ -58,36 +61,23 @@ func ClaimPositionFee() {
 	fmt.Printf("Fee Token B: %s\n", unclaimedReward.FeeTokenB.String())
 
 	// 7) derive token accounts
-	tokenAAccount, _, _ := solana.FindAssociatedTokenAddress(
-		userWallet,
-		poolState.TokenAMint,
-	)
-	tokenBAccount, _, _ := solana.FindAssociatedTokenAddress(
-		userWallet,
-		poolState.TokenBMint,
-	)
+	tokenAAccount, err := helpers.DeriveAssociatedTokenAddress(userWallet, poolState.TokenAMint, tokenAProgram)
+	if err != nil {
+		log.Fatalf("Failed to derive token A account: %v", err)
+	}
+	tokenBAccount, err := helpers.DeriveAssociatedTokenAddress(userWallet, poolState.TokenBMint, tokenBProgram)
+	if err != nil {
+		log.Fatalf("Failed to derive token B account: %v", err)
+	}
 
 	// 8) create ATAs if they dont exist
-	var createTokenAAtaIx, createTokenBAtaIx solana.Instruction
-
-	// check if token A ATA exists
-	accountInfo, err := client.GetAccountInfo(ctx, tokenAAccount)
-	if err != nil || accountInfo == nil || accountInfo.Value == nil {
-		createTokenAAtaIx = associatedtokenaccount.NewCreateInstruction(
-			userWallet,
-			userWallet,
-			poolState.TokenAMint,
-		).Build()
+	createTokenAAtaIx, err := instructions.CreateAssociatedTokenAccountIdempotent(userWallet, userWallet, poolState.TokenAMint, tokenAProgram)
+	if err != nil {
+		log.Fatalf("Failed to build token A account instruction: %v", err)
 	}
-
-	// check if token B ATA exists
-	accountInfo, err = client.GetAccountInfo(ctx, tokenBAccount)
-	if err != nil || accountInfo == nil || accountInfo.Value == nil {
-		createTokenBAtaIx = associatedtokenaccount.NewCreateInstruction(
-			userWallet,
-			userWallet,
-			poolState.TokenBMint,
-		).Build()
+	createTokenBAtaIx, err := instructions.CreateAssociatedTokenAccountIdempotent(userWallet, userWallet, poolState.TokenBMint, tokenBProgram)
+	if err != nil {
+		log.Fatalf("Failed to build token B account instruction: %v", err)
 	}
 
 	// 9) build claim position fee instruction
@@ -100,36 +90,29 @@ func ClaimPositionFee() {
 		poolState.TokenBVault,
 		poolState.TokenAMint,
 		poolState.TokenBMint,
-		solana.TokenProgramID,
-		solana.TokenProgramID,
+		tokenAProgram,
+		tokenBProgram,
 		positions[0].PositionNftAccount,
 		userWallet,
 	)
 
-	// 10) assemble transaction
-	bh, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	// 10) assemble transaction with a compute budget priced from recent fees on the pool accounts
+	// and sized from a simulation, ATA creation is a no-op when the accounts exist
+	builder := transaction.NewBuilder(client, userWallet)
+	built, simulation, err := builder.BuildSimulated(ctx, createTokenAAtaIx, createTokenBAtaIx, ixClaim)
 	if err != nil {
-		log.Fatalf("GetLatestBlockhash: %v", err)
-	}
-
-	// create instructions slice
-	instructions := []solana.Instruction{ixClaim}
-
-	// add ATA creation instructions only if needed
-	if createTokenAAtaIx != nil {
-		instructions = append([]solana.Instruction{createTokenAAtaIx}, instructions...)
+		var txErr *transaction.TransactionError
+		if errors.As(err, &txErr) {
+			for _, line := range txErr.Logs {
+				fmt.Println(line)
+			}
+		}
+		log.Fatalf("BuildSimulated: %v", err)
 	}
-	if createTokenBAtaIx != nil {
-		instructions = append([]solana.Instruction{createTokenBAtaIx}, instructions...)
-	}
-
-	tx, err := solana.NewTransaction(
-		instructions,
-		bh.Value.Blockhash,
-		solana.TransactionPayer(userWallet),
-	)
-	if err != nil {
-		log.Fatalf("NewTransaction: %v", err)
+	tx := built.Transaction
+	fmt.Printf("Compute units: %d at %d micro-lamports\n", built.ComputeUnitLimit, built.ComputeUnitPrice)
+	for _, change := range simulation.BalanceChanges {
+		fmt.Printf("Balance change of %s (%s): %s\n", change.Account, change.Mint, change.Delta)
 	}
 
 	// 11) sign transaction
@@ -143,31 +126,21 @@ func ClaimPositionFee() {
 		log.Fatalf("Sign: %v", err)
 	}
 
-	// 12) send & confirm
-	sig, err := client.SendTransaction(ctx, tx)
+	// 12) send, rebroadcasting until the transaction lands or its blockhash expires
+	sender := transaction.NewSender(client)
+	outcome, err := sender.Send(ctx, built)
 	if err != nil {
-		log.Fatalf("SendTransaction: %v", err)
+		log.Fatalf("Send: %v", err)
 	}
-	fmt.Printf("Transaction sent: %s\n", sig)
-
-	// wait for confirmation by polling
-	for i := 0; i < 30; i++ { // try for 30 secs
-		time.Sleep(time.Second)
-		resp, err := client.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
-			Commitment: rpc.CommitmentFinalized,
-		})
-		if err != nil {
-			continue
-		}
-		if resp != nil {
-			if resp.Meta != nil && resp.Meta.Err != nil {
-				log.Fatalf("Transaction failed: %v", resp.Meta.Err)
-			}
-			fmt.Printf("Transaction confirmed: %s\n", `https://solscan.io/tx/`+sig.String())
-			return
-		}
+
+	switch outcome.Status {
+	case transaction.OutcomeLanded:
+		fmt.Printf("Transaction confirmed: %s\n", `https://solscan.io/tx/`+outcome.Signature.String())
+	case transaction.OutcomeFailed:
+		log.Fatalf("Transaction failed: %v", outcome.Err)
+	case transaction.OutcomeExpired:
+		log.Fatalf("Transaction expired after %d sends", outcome.Sends)
 	}
-	log.Fatalf("Transaction confirmation timeout")
 }
 
 func main() {
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/dannwee/dbc-go/stats"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func CrawlProtocolStats() {
+	rpcClient := rpc.New("https://api.mainnet-beta.solana.com")
+
+	fmt.Println("Crawling protocol stats...")
+	ctx := context.Background()
+
+	protocolStats, err := stats.Crawl(ctx, rpcClient, stats.CrawlOptions{RequestsPerSecond: 5})
+	if err != nil {
+		log.Fatalf("Failed to crawl protocol stats: %v", err)
+	}
+
+	fileName := fmt.Sprintf("stats-%s.json", time.Now().UTC().Format("2006-01-02"))
+	file, err := os.Create(fileName)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", fileName, err)
+	}
+	defer file.Close()
+
+	if err := stats.WriteJSON(file, protocolStats); err != nil {
+		log.Fatalf("Failed to write protocol stats: %v", err)
+	}
+
+	fmt.Printf("Crawled %d pools and %d positions into %s\n", protocolStats.Pools, protocolStats.Positions, fileName)
+}
+
+// func main() {
+// 	CrawlProtocolStats()
+// }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/dannwee/dbc-go/helpers"
+	"github.com/dannwee/dbc-go/instructions"
+	"github.com/dannwee/dbc-go/transaction"
+)
+
+func CreateLookupTable() {
+	ctx := context.Background()
+	client := rpc.New("https://api.mainnet-beta.solana.com")
+
+	// 1) load user keypair
+	userKeypair := solana.MustPrivateKeyFromBase58("YOUR_PRIVATE_KEY")
+	userWallet := userKeypair.PublicKey()
+
+	// 2) pool address
+	poolAddress := solana.MustPublicKeyFromBase58("YOUR_POOL_ADDRESS")
+
+	pool, err := instructions.GetPool(ctx, poolAddress, client)
+	if err != nil {
+		log.Fatalf("Failed to get pool: %v", err)
+	}
+
+	// 3) fill a table with the static cp_amm accounts and the pool's vaults and mints
+	addresses := append(helpers.StaticLookupTableAddresses(), helpers.PoolLookupTableAddresses(poolAddress, pool)...)
+
+	lookupTables := transaction.NewLookupTableCache(client)
+	table, groups, err := lookupTables.Create(ctx, userWallet, userWallet, addresses)
+	if err != nil {
+		log.Fatalf("Failed to prepare lookup table: %v", err)
+	}
+	fmt.Printf("Lookup table: %s\n", table)
+
+	// 4) send each group of instructions in its own transaction
+	builder := transaction.NewBuilder(client, userWallet)
+	sender := transaction.NewSender(client)
+	for _, group := range groups {
+		built, err := builder.Build(ctx, group...)
+		if err != nil {
+			log.Fatalf("Build: %v", err)
+		}
+
+		_, err = built.Transaction.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+			if key.Equals(userWallet) {
+				return &userKeypair
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("Sign: %v", err)
+		}
+
+		outcome, err := sender.Send(ctx, built)
+		if err != nil {
+			log.Fatalf("Send: %v", err)
+		}
+		if outcome.Status != transaction.OutcomeLanded {
+			log.Fatalf("Lookup table transaction %s: %v", outcome.Status, outcome.Err)
+		}
+	}
+
+	// 5) later transactions built with the cache are compiled as v0 messages using the table
+	if err := lookupTables.Load(ctx, table); err != nil {
+		log.Fatalf("Failed to load lookup table: %v", err)
+	}
+	builder.LookupTables = lookupTables
+
+	fmt.Printf("Lookup table holds %d addresses\n", len(lookupTables.Tables()[table]))
+}
+
+// func main() {
+// 	CreateLookupTable()
+// }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/dannwee/dbc-go/helpers"
+	"github.com/dannwee/dbc-go/instructions"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func GetPoolInfo() {
+	rpcClient := rpc.New("https://api.mainnet-beta.solana.com")
+
+	poolAddressStr := "YOUR_POOL_ADDRESS"
+
+	fmt.Println("Getting pool info...")
+	poolAddress := solana.MustPublicKeyFromBase58(poolAddressStr)
+
+	ctx := context.Background()
+
+	poolInfo, err := instructions.GetPoolInfo(ctx, poolAddress, rpcClient)
+	if err != nil {
+		log.Fatalf("Failed to get pool info: %v", err)
+	}
+
+	jsonData, err := json.MarshalIndent(poolInfo, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal pool info to JSON: %v", err)
+	}
+
+	fmt.Printf("Pool info: %s\n", string(jsonData))
+	fmt.Printf("Price: %s\n", helpers.FormatPrice(poolInfo.Price, 8))
+}
+
+// func main() {
+// 	GetPoolInfo()
+// }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/dannwee/dbc-go/instructions"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func GetPositionOwner() {
+	rpcClient := rpc.New("https://api.mainnet-beta.solana.com")
+
+	positionAddressStr := "YOUR_POSITION_ADDRESS"
+
+	fmt.Println("Getting position owner...")
+	positionAddress := solana.MustPublicKeyFromBase58(positionAddressStr)
+
+	ctx := context.Background()
+
+	owner, err := instructions.GetPositionOwner(ctx, positionAddress, rpcClient)
+	if err != nil {
+		log.Fatalf("Failed to get position owner: %v", err)
+	}
+
+	jsonData, err := json.MarshalIndent(owner, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal position owner to JSON: %v", err)
+	}
+
+	fmt.Printf("Position owner: %s\n", string(jsonData))
+}
+
+// func main() {
+// 	GetPositionOwner()
+// }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dannwee/dbc-go/damm"
+	"github.com/dannwee/dbc-go/helpers"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func SubscribePool() {
+	client := damm.NewClientFromURL("https://api.mainnet-beta.solana.com", damm.WithCommitment(rpc.CommitmentConfirmed))
+	subscriber := damm.NewSubscriber(client, "wss://api.mainnet-beta.solana.com")
+
+	poolAddressStr := "YOUR_POOL_ADDRESS"
+
+	fmt.Println("Subscribing to pool...")
+	poolAddress := solana.MustPublicKeyFromBase58(poolAddressStr)
+
+	ctx := context.Background()
+
+	updates, err := subscriber.SubscribePool(ctx, poolAddress)
+	if err != nil {
+		log.Fatalf("Failed to subscribe to pool: %v", err)
+	}
+
+	for update := range updates {
+		price := helpers.SqrtPriceToPrice(update.PoolState.SqrtPrice, 0, 0)
+		fmt.Printf("Slot %d: liquidity %s, raw price %s\n", update.Slot, update.PoolState.Liquidity, helpers.FormatPrice(price, 8))
+	}
+}
+
+// func main() {
+// 	SubscribePool()
+// }
@@ -1,6 +1,9 @@
 package helpers
 
 import (
+	"bytes"
+	"encoding/binary"
+
 	"github.com/dannwee/dbc-go/common"
 	"github.com/gagliardetto/solana-go"
 )
@@ -27,10 +30,101 @@ func DerivePoolAuthorityPDA() solana.PublicKey {
 
 // Derives the position PDA from a position NFT mint
 func DerivePositionPDA(positionNft solana.PublicKey) (solana.PublicKey, error) {
+	return DerivePositionPDAForProgram(positionNft, solana.MustPublicKeyFromBase58(common.DammV2ProgramID))
+}
+
+// Derives the position PDA from a position NFT mint for a given cp_amm deployment
+func DerivePositionPDAForProgram(positionNft solana.PublicKey, programID solana.PublicKey) (solana.PublicKey, error) {
 	seeds := [][]byte{[]byte("position"), positionNft.Bytes()}
-	address, _, err := solana.FindProgramAddress(seeds, solana.MustPublicKeyFromBase58(common.DammV2ProgramID))
+	address, _, err := solana.FindProgramAddress(seeds, programID)
 	if err != nil {
 		return solana.PublicKey{}, err
 	}
 	return address, nil
 }
+
+// Derives the position NFT account PDA, the token account the NFT is minted into
+func DerivePositionNftAccountPDA(positionNft solana.PublicKey) (solana.PublicKey, error) {
+	return DerivePositionNftAccountPDAForProgram(positionNft, solana.MustPublicKeyFromBase58(common.DammV2ProgramID))
+}
+
+// Derives the position NFT account PDA for a given cp_amm deployment
+func DerivePositionNftAccountPDAForProgram(positionNft solana.PublicKey, programID solana.PublicKey) (solana.PublicKey, error) {
+	seeds := [][]byte{[]byte("position_nft_account"), positionNft.Bytes()}
+	address, _, err := solana.FindProgramAddress(seeds, programID)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	return address, nil
+}
+
+// Derives the pool PDA from its config and token mints, in either order
+func DerivePoolPDA(config solana.PublicKey, tokenAMint solana.PublicKey, tokenBMint solana.PublicKey) (solana.PublicKey, error) {
+	return DerivePoolPDAForProgram(config, tokenAMint, tokenBMint, solana.MustPublicKeyFromBase58(common.DammV2ProgramID))
+}
+
+// Derives the pool PDA from its config and token mints for a given cp_amm deployment
+func DerivePoolPDAForProgram(config solana.PublicKey, tokenAMint solana.PublicKey, tokenBMint solana.PublicKey, programID solana.PublicKey) (solana.PublicKey, error) {
+	firstKey, secondKey := tokenAMint, tokenBMint
+	if bytes.Compare(tokenAMint[:], tokenBMint[:]) < 0 {
+		firstKey, secondKey = tokenBMint, tokenAMint
+	}
+
+	seeds := [][]byte{[]byte("pool"), config.Bytes(), firstKey.Bytes(), secondKey.Bytes()}
+	address, _, err := solana.FindProgramAddress(seeds, programID)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	return address, nil
+}
+
+// Derives the associated token account of a wallet for a mint owned by the given token program
+func DeriveAssociatedTokenAddress(wallet solana.PublicKey, mint solana.PublicKey, tokenProgram solana.PublicKey) (solana.PublicKey, error) {
+	seeds := [][]byte{wallet.Bytes(), tokenProgram.Bytes(), mint.Bytes()}
+	address, _, err := solana.FindProgramAddress(seeds, solana.SPLAssociatedTokenAccountProgramID)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	return address, nil
+}
+
+// Derives the address lookup table created by authority at recentSlot, with its bump
+func DeriveLookupTableAddress(authority solana.PublicKey, recentSlot uint64) (solana.PublicKey, uint8, error) {
+	slot := make([]byte, 8)
+	binary.LittleEndian.PutUint64(slot, recentSlot)
+	seeds := [][]byte{authority.Bytes(), slot}
+	return solana.FindProgramAddress(seeds, solana.MustPublicKeyFromBase58(common.AddressLookupTableProgram))
+}
+
+// StaticLookupTableAddresses returns the accounts shared by every cp_amm instruction:
+// the program, its pool and event authorities and the token programs
+func StaticLookupTableAddresses() solana.PublicKeySlice {
+	return solana.PublicKeySlice{
+		solana.MustPublicKeyFromBase58(common.DammV2ProgramID),
+		DerivePoolAuthorityPDA(),
+		DeriveEventAuthorityPDA(),
+		solana.TokenProgramID,
+		solana.Token2022ProgramID,
+		solana.SystemProgramID,
+		solana.SPLAssociatedTokenAccountProgramID,
+	}
+}
+
+// PoolLookupTableAddresses returns the accounts of a pool used by its instructions:
+// the pool, its vaults and mints, and the vaults and mints of initialized rewards
+func PoolLookupTableAddresses(poolAddress solana.PublicKey, pool *common.Pool) solana.PublicKeySlice {
+	addresses := solana.PublicKeySlice{
+		poolAddress,
+		pool.TokenAVault,
+		pool.TokenBVault,
+		pool.TokenAMint,
+		pool.TokenBMint,
+	}
+	for _, reward := range pool.RewardInfos {
+		if reward.Initialized != 0 {
+			addresses.UniqueAppend(reward.Vault)
+			addresses.UniqueAppend(reward.Mint)
+		}
+	}
+	return addresses
+}
@@ -0,0 +1,120 @@
+package helpers
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+)
+
+const SecondsPerYear = 365 * 24 * 60 * 60
+
+// NewPoolSnapshot captures the fields of a pool needed for fee APR estimation
+func NewPoolSnapshot(poolAddress solana.PublicKey, pool *common.Pool, timestamp int64, slot uint64) common.PoolSnapshot {
+	return common.PoolSnapshot{
+		Pool:           poolAddress,
+		Timestamp:      timestamp,
+		Slot:           slot,
+		SqrtPrice:      pool.SqrtPrice,
+		SqrtMinPrice:   pool.SqrtMinPrice,
+		SqrtMaxPrice:   pool.SqrtMaxPrice,
+		Liquidity:      pool.Liquidity,
+		CollectFeeMode: pool.CollectFeeMode,
+		TotalLpAFee:    pool.Metrics.TotalLpAFee,
+		TotalLpBFee:    pool.Metrics.TotalLpBFee,
+	}
+}
+
+// getSnapshotTVL returns the value of the pool's liquidity in the smallest unit of token B
+func getSnapshotTVL(snapshot common.PoolSnapshot) *big.Rat {
+	pool := &common.Pool{
+		SqrtPrice:    snapshot.SqrtPrice,
+		SqrtMinPrice: snapshot.SqrtMinPrice,
+		SqrtMaxPrice: snapshot.SqrtMaxPrice,
+	}
+	amountA, amountB := GetAmountsForLiquidity(pool, snapshot.Liquidity, RoundingDown)
+
+	tvl := new(big.Rat).Mul(new(big.Rat).SetInt(amountA), SqrtPriceToPrice(snapshot.SqrtPrice, 0, 0))
+	return tvl.Add(tvl, new(big.Rat).SetInt(amountB))
+}
+
+// EstimateFeeAPR annualizes the LP fee growth between two snapshots of the same pool against its TVL.
+// Token A fees are valued at the end price. Only B pools never accrue token A fees, so only token B counts there.
+func EstimateFeeAPR(start common.PoolSnapshot, end common.PoolSnapshot) (*common.FeeAPR, error) {
+	if !start.Pool.Equals(end.Pool) {
+		return nil, fmt.Errorf("snapshots belong to different pools: %s, %s", start.Pool, end.Pool)
+	}
+	if end.Timestamp <= start.Timestamp {
+		return nil, fmt.Errorf("end snapshot must be later than start snapshot")
+	}
+	if err := end.CollectFeeMode.Validate(); err != nil {
+		return nil, err
+	}
+	if end.TotalLpAFee.Cmp(start.TotalLpAFee) < 0 || end.TotalLpBFee.Cmp(start.TotalLpBFee) < 0 {
+		return nil, fmt.Errorf("fee counters decreased between snapshots")
+	}
+
+	elapsed := end.Timestamp - start.Timestamp
+	lpAFee := end.TotalLpAFee.Sub(start.TotalLpAFee)
+	lpBFee := end.TotalLpBFee.Sub(start.TotalLpBFee)
+
+	feeValue := new(big.Rat).SetInt(lpBFee.Big())
+	if end.CollectFeeMode == common.CollectFeeModeBothToken {
+		feeAValue := new(big.Rat).Mul(new(big.Rat).SetInt(lpAFee.Big()), SqrtPriceToPrice(end.SqrtPrice, 0, 0))
+		feeValue.Add(feeValue, feeAValue)
+	}
+
+	tvl := new(big.Rat).Add(getSnapshotTVL(start), getSnapshotTVL(end))
+	tvl.Quo(tvl, big.NewRat(2, 1))
+
+	apr := new(big.Rat)
+	if tvl.Sign() > 0 {
+		apr.Quo(feeValue, tvl)
+		apr.Mul(apr, big.NewRat(SecondsPerYear*100, elapsed))
+	}
+
+	return &common.FeeAPR{
+		ElapsedSeconds: elapsed,
+		LpAFee:         lpAFee,
+		LpBFee:         lpBFee,
+		FeeValue:       feeValue,
+		TVL:            tvl,
+		APR:            apr,
+	}, nil
+}
+
+// EstimatePositionFeeAPR estimates a position's fee APR from its share of the pool liquidity at the end snapshot
+func EstimatePositionFeeAPR(start common.PoolSnapshot, end common.PoolSnapshot, position *common.PositionState) (*common.PositionFeeAPR, error) {
+	if !position.Pool.Equals(end.Pool) {
+		return nil, fmt.Errorf("position belongs to pool %s, not %s", position.Pool, end.Pool)
+	}
+
+	poolAPR, err := EstimateFeeAPR(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	share := new(big.Rat)
+	if !end.Liquidity.IsZero() {
+		share.SetFrac(getPositionTotalLiquidity(position).Big(), end.Liquidity.Big())
+	}
+
+	// All positions share the same price range, so the position earns its liquidity share of the fees
+	positionFeeValue := new(big.Rat).Mul(poolAPR.FeeValue, share)
+	positionValue := new(big.Rat).Mul(getSnapshotTVL(end), share)
+
+	apr := new(big.Rat)
+	if positionValue.Sign() > 0 {
+		apr.Quo(positionFeeValue, positionValue)
+		apr.Mul(apr, big.NewRat(SecondsPerYear*100, poolAPR.ElapsedSeconds))
+	}
+
+	return &common.PositionFeeAPR{
+		Pool:             *poolAPR,
+		Share:            share,
+		PositionFeeValue: positionFeeValue,
+		PositionValue:    positionValue,
+		APR:              apr,
+	}, nil
+}
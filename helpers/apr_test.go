@@ -0,0 +1,79 @@
+package helpers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"lukechampine.com/uint128"
+)
+
+// testSnapshot is a pool at price 1 between 0.25 and 4 holding 1000 of each token, with the given LP fee counters
+func testSnapshot(pool solana.PublicKey, timestamp int64, lpAFee uint64, lpBFee uint64) common.PoolSnapshot {
+	oneQ64 := uint128.From64(1).Lsh(common.ScaleOffset)
+	return common.PoolSnapshot{
+		Pool:         pool,
+		Timestamp:    timestamp,
+		SqrtPrice:    oneQ64,
+		SqrtMinPrice: oneQ64.Rsh(1),
+		SqrtMaxPrice: oneQ64.Mul64(2),
+		Liquidity:    uint128.From64(2_000).Lsh(common.ScaleOffset),
+		TotalLpAFee:  uint128.From64(lpAFee),
+		TotalLpBFee:  uint128.From64(lpBFee),
+	}
+}
+
+func TestEstimateFeeAPR(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+	start := testSnapshot(pool, 0, 5, 5)
+	end := testSnapshot(pool, 24*60*60, 15, 15)
+
+	apr, err := EstimateFeeAPR(start, end)
+	if err != nil {
+		t.Fatalf("failed to estimate fee APR: %v", err)
+	}
+	if apr.TVL.Cmp(big.NewRat(2_000, 1)) != 0 || apr.FeeValue.Cmp(big.NewRat(20, 1)) != 0 {
+		t.Fatalf("expected 20 of fees on a TVL of 2000, got %s on %s", apr.FeeValue.RatString(), apr.TVL.RatString())
+	}
+	// 1% a day
+	if apr.APR.Cmp(big.NewRat(365, 1)) != 0 {
+		t.Fatalf("expected an APR of 365%%, got %s%%", apr.APR.FloatString(4))
+	}
+
+	// Only B pools count token B fees only
+	end.CollectFeeMode = common.CollectFeeModeOnlyB
+	if apr, err = EstimateFeeAPR(start, end); err != nil || apr.APR.Cmp(big.NewRat(365, 2)) != 0 {
+		t.Fatalf("expected an APR of 182.5%% counting token B fees only, got %v, %v", apr, err)
+	}
+
+	position := &common.PositionState{Pool: pool, UnlockedLiquidity: end.Liquidity.Rsh(1)}
+	positionAPR, err := EstimatePositionFeeAPR(start, end, position)
+	if err != nil {
+		t.Fatalf("failed to estimate position fee APR: %v", err)
+	}
+	if positionAPR.Share.Cmp(big.NewRat(1, 2)) != 0 || positionAPR.APR.Cmp(apr.APR) != 0 {
+		t.Fatalf("expected half of the pool earning the pool APR, got %s at %s%%", positionAPR.Share.RatString(), positionAPR.APR.FloatString(4))
+	}
+}
+
+func TestEstimateFeeAPRRejectsInvalidWindows(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+
+	tests := []struct {
+		name  string
+		start common.PoolSnapshot
+		end   common.PoolSnapshot
+	}{
+		{name: "different pools", start: testSnapshot(pool, 0, 0, 0), end: testSnapshot(solana.NewWallet().PublicKey(), 10, 0, 0)},
+		{name: "same timestamp", start: testSnapshot(pool, 10, 0, 0), end: testSnapshot(pool, 10, 1, 1)},
+		{name: "end before start", start: testSnapshot(pool, 10, 0, 0), end: testSnapshot(pool, 5, 1, 1)},
+		{name: "fee counter decreased", start: testSnapshot(pool, 0, 10, 10), end: testSnapshot(pool, 10, 9, 20)},
+	}
+
+	for _, tt := range tests {
+		if _, err := EstimateFeeAPR(tt.start, tt.end); err == nil {
+			t.Fatalf("%s: expected an error", tt.name)
+		}
+	}
+}
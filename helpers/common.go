@@ -96,7 +96,7 @@ func DeserializePool(data []byte) (*common.Pool, error) {
 	pool.PoolStatus = data[9]
 	pool.TokenAFlag = data[10]
 	pool.TokenBFlag = data[11]
-	pool.CollectFeeMode = data[12]
+	pool.CollectFeeMode = common.CollectFeeMode(data[12])
 	pool.PoolType = data[13]
 	data = data[14:]
 
@@ -203,3 +203,77 @@ func DeserializePosition(data []byte) (*common.PositionState, error) {
 
 	return position, nil
 }
+
+// Deserializes config data
+func DeserializeConfig(data []byte) (*common.Config, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("data too short")
+	}
+
+	// Skip discriminator
+	data = data[8:]
+
+	config := &common.Config{}
+
+	// Read PublicKeys
+	config.VaultConfigKey = solana.PublicKeyFromBytes(data[0:32])
+	config.PoolCreatorAuthority = solana.PublicKeyFromBytes(data[32:64])
+	data = data[64:]
+
+	// BaseFee
+	config.PoolFees.BaseFee.CliffFeeNumerator = binary.LittleEndian.Uint64(data[0:8])
+	config.PoolFees.BaseFee.FeeSchedulerMode = data[8]
+	copy(config.PoolFees.BaseFee.Padding[:], data[9:14])
+	config.PoolFees.BaseFee.NumberOfPeriod = binary.LittleEndian.Uint16(data[14:16])
+	config.PoolFees.BaseFee.PeriodFrequency = binary.LittleEndian.Uint64(data[16:24])
+	config.PoolFees.BaseFee.ReductionFactor = binary.LittleEndian.Uint64(data[24:32])
+	data = data[32:]
+
+	// DynamicFee
+	config.PoolFees.DynamicFee.Initialized = data[0]
+	copy(config.PoolFees.DynamicFee.Padding[:], data[1:8])
+	config.PoolFees.DynamicFee.MaxVolatilityAccumulator = binary.LittleEndian.Uint32(data[8:12])
+	config.PoolFees.DynamicFee.VariableFeeControl = binary.LittleEndian.Uint32(data[12:16])
+	config.PoolFees.DynamicFee.BinStep = binary.LittleEndian.Uint16(data[16:18])
+	config.PoolFees.DynamicFee.FilterPeriod = binary.LittleEndian.Uint16(data[18:20])
+	config.PoolFees.DynamicFee.DecayPeriod = binary.LittleEndian.Uint16(data[20:22])
+	config.PoolFees.DynamicFee.ReductionFactor = binary.LittleEndian.Uint16(data[22:24])
+	copy(config.PoolFees.DynamicFee.Padding1[:], data[24:32])
+	config.PoolFees.DynamicFee.BinStepU128 = uint128.From64(binary.LittleEndian.Uint64(data[32:40])).Add(uint128.From64(binary.LittleEndian.Uint64(data[40:48])).Lsh(64))
+	data = data[48:]
+
+	// ProtocolFeePercent, PartnerFeePercent, ReferralFeePercent
+	config.PoolFees.ProtocolFeePercent = data[0]
+	config.PoolFees.PartnerFeePercent = data[1]
+	config.PoolFees.ReferralFeePercent = data[2]
+	copy(config.PoolFees.Padding0[:], data[3:8])
+	data = data[8:]
+
+	// Padding1
+	for i := 0; i < 5; i++ {
+		config.PoolFees.Padding1[i] = binary.LittleEndian.Uint64(data[i*8 : (i+1)*8])
+	}
+	data = data[40:]
+
+	// Read uint8 values
+	config.ActivationType = data[0]
+	config.CollectFeeMode = common.CollectFeeMode(data[1])
+	config.ConfigType = data[2]
+	copy(config.Padding0[:], data[3:8])
+	data = data[8:]
+
+	config.Index = binary.LittleEndian.Uint64(data[0:8])
+	data = data[8:]
+
+	// Read uint128 values for prices
+	config.SqrtMinPrice = uint128.From64(binary.LittleEndian.Uint64(data[0:8])).Add(uint128.From64(binary.LittleEndian.Uint64(data[8:16])).Lsh(64))
+	config.SqrtMaxPrice = uint128.From64(binary.LittleEndian.Uint64(data[16:24])).Add(uint128.From64(binary.LittleEndian.Uint64(data[24:32])).Lsh(64))
+	data = data[32:]
+
+	// Read padding1
+	for i := 0; i < 10; i++ {
+		config.Padding1[i] = binary.LittleEndian.Uint64(data[i*8 : (i+1)*8])
+	}
+
+	return config, nil
+}
@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"math/big"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+// GetDeltaAmountA returns the token A amount for liquidity between two sqrt prices
+// Δa = L * (√P_upper - √P_lower) / (√P_upper * √P_lower)
+func GetDeltaAmountA(lowerSqrtPrice uint128.Uint128, upperSqrtPrice uint128.Uint128, liquidity uint128.Uint128, rounding Rounding) *big.Int {
+	if upperSqrtPrice.Cmp(lowerSqrtPrice) <= 0 || lowerSqrtPrice.IsZero() {
+		return new(big.Int)
+	}
+
+	numerator := new(big.Int).Sub(upperSqrtPrice.Big(), lowerSqrtPrice.Big())
+	denominator := new(big.Int).Mul(lowerSqrtPrice.Big(), upperSqrtPrice.Big())
+	return mulDiv(liquidity.Big(), numerator, denominator, rounding)
+}
+
+// GetDeltaAmountB returns the token B amount for liquidity between two sqrt prices
+// Δb = L * (√P_upper - √P_lower)
+func GetDeltaAmountB(lowerSqrtPrice uint128.Uint128, upperSqrtPrice uint128.Uint128, liquidity uint128.Uint128, rounding Rounding) *big.Int {
+	if upperSqrtPrice.Cmp(lowerSqrtPrice) <= 0 {
+		return new(big.Int)
+	}
+
+	deltaSqrtPrice := new(big.Int).Sub(upperSqrtPrice.Big(), lowerSqrtPrice.Big())
+	denominator := new(big.Int).Lsh(big.NewInt(1), 2*common.ScaleOffset)
+	return mulDiv(liquidity.Big(), deltaSqrtPrice, denominator, rounding)
+}
+
+// GetAmountsForLiquidity returns the token amounts backing liquidity at the pool's current price
+func GetAmountsForLiquidity(pool *common.Pool, liquidity uint128.Uint128, rounding Rounding) (*big.Int, *big.Int) {
+	amountA := GetDeltaAmountA(pool.SqrtPrice, pool.SqrtMaxPrice, liquidity, rounding)
+	amountB := GetDeltaAmountB(pool.SqrtMinPrice, pool.SqrtPrice, liquidity, rounding)
+	return amountA, amountB
+}
+
+// toUint64 converts x to a uint64, saturating at the maximum value
+func toUint64(x *big.Int) uint64 {
+	if x.Sign() <= 0 {
+		return 0
+	}
+	if !x.IsUint64() {
+		return ^uint64(0)
+	}
+	return x.Uint64()
+}
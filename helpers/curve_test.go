@@ -0,0 +1,137 @@
+package helpers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+// exactDeltaAmounts computes Δa and Δb between two sqrt prices without rounding
+func exactDeltaAmounts(lower uint128.Uint128, upper uint128.Uint128, liquidity uint128.Uint128) (*big.Rat, *big.Rat) {
+	delta := new(big.Int).Sub(upper.Big(), lower.Big())
+
+	amountA := new(big.Rat).SetFrac(new(big.Int).Mul(liquidity.Big(), delta), new(big.Int).Mul(lower.Big(), upper.Big()))
+	amountB := new(big.Rat).SetFrac(new(big.Int).Mul(liquidity.Big(), delta), new(big.Int).Lsh(big.NewInt(1), 2*common.ScaleOffset))
+	return amountA, amountB
+}
+
+// checkRounding fails unless got is exact rounded in the given direction
+func checkRounding(t *testing.T, name string, got *big.Int, exact *big.Rat, rounding Rounding) {
+	t.Helper()
+
+	floor := new(big.Int).Quo(exact.Num(), exact.Denom())
+	want := floor
+	if rounding == RoundingUp && !exact.IsInt() {
+		want = new(big.Int).Add(floor, big.NewInt(1))
+	}
+	if got.Cmp(want) != 0 {
+		t.Fatalf("expected %s rounded %v to be %s, got %s", name, rounding, want, got)
+	}
+}
+
+func TestGetDeltaAmountsRounding(t *testing.T) {
+	oneQ64 := uint128.From64(1).Lsh(common.ScaleOffset)
+
+	tests := []struct {
+		name      string
+		lower     uint128.Uint128
+		upper     uint128.Uint128
+		liquidity uint128.Uint128
+	}{
+		{name: "exact", lower: oneQ64, upper: oneQ64.Mul64(2), liquidity: oneQ64.Mul64(1_000)},
+		{name: "inexact", lower: oneQ64, upper: oneQ64.Add64(12_345_678_901), liquidity: uint128.From64(987_654_321_987)},
+		{name: "full range", lower: MinSqrtPrice, upper: MaxSqrtPrice, liquidity: oneQ64.Mul64(3)},
+		{name: "dust", lower: oneQ64, upper: oneQ64.Add64(1), liquidity: uint128.From64(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exactA, exactB := exactDeltaAmounts(tt.lower, tt.upper, tt.liquidity)
+			for _, rounding := range []Rounding{RoundingDown, RoundingUp} {
+				checkRounding(t, "Δa", GetDeltaAmountA(tt.lower, tt.upper, tt.liquidity, rounding), exactA, rounding)
+				checkRounding(t, "Δb", GetDeltaAmountB(tt.lower, tt.upper, tt.liquidity, rounding), exactB, rounding)
+			}
+		})
+	}
+
+	// An empty or inverted range holds nothing
+	if amount := GetDeltaAmountA(oneQ64, oneQ64, oneQ64, RoundingUp); amount.Sign() != 0 {
+		t.Fatalf("expected no token A in an empty range, got %s", amount)
+	}
+	if amount := GetDeltaAmountB(oneQ64.Mul64(2), oneQ64, oneQ64, RoundingUp); amount.Sign() != 0 {
+		t.Fatalf("expected no token B in an inverted range, got %s", amount)
+	}
+}
+
+func TestGetAmountsForLiquidity(t *testing.T) {
+	pool := &common.Pool{
+		SqrtPrice:    uint128.From64(1).Lsh(common.ScaleOffset).Mul64(3).Div64(2),
+		SqrtMinPrice: uint128.From64(1).Lsh(common.ScaleOffset),
+		SqrtMaxPrice: uint128.From64(1).Lsh(common.ScaleOffset).Mul64(2),
+	}
+	liquidity := uint128.From64(1_000_000_007).Lsh(common.ScaleOffset).Add64(1)
+
+	// Token A sits above the current price and token B below it
+	exactA, _ := exactDeltaAmounts(pool.SqrtPrice, pool.SqrtMaxPrice, liquidity)
+	_, exactB := exactDeltaAmounts(pool.SqrtMinPrice, pool.SqrtPrice, liquidity)
+
+	for _, rounding := range []Rounding{RoundingDown, RoundingUp} {
+		amountA, amountB := GetAmountsForLiquidity(pool, liquidity, rounding)
+		checkRounding(t, "token A", amountA, exactA, rounding)
+		checkRounding(t, "token B", amountB, exactB, rounding)
+	}
+}
+
+func TestValuePositionRoundsDown(t *testing.T) {
+	oneQ64 := uint128.From64(1).Lsh(common.ScaleOffset)
+	pool := &common.Pool{
+		SqrtPrice:    oneQ64.Mul64(3).Div64(2),
+		SqrtMinPrice: oneQ64,
+		SqrtMaxPrice: oneQ64.Mul64(2),
+	}
+	position := &common.PositionState{
+		UnlockedLiquidity:        uint128.From64(333_333_333_333_333),
+		VestedLiquidity:          uint128.From64(777_777_777_777_777),
+		PermanentLockedLiquidity: uint128.From64(555_555_555_555_555),
+		FeeAPending:              9,
+	}
+	pool.Liquidity = getPositionTotalLiquidity(position)
+
+	value, err := ValuePositionAt(pool, position, 0)
+	if err != nil {
+		t.Fatalf("failed to value position: %v", err)
+	}
+
+	// Removing liquidity rounds both tokens down in the program
+	buckets := map[string]struct {
+		liquidity uint128.Uint128
+		amounts   common.TokenAmounts
+	}{
+		"unlocked":         {position.UnlockedLiquidity, value.Unlocked},
+		"vested":           {position.VestedLiquidity, value.Vested},
+		"permanent locked": {position.PermanentLockedLiquidity, value.PermanentLocked},
+		"total":            {pool.Liquidity, value.Total},
+	}
+	for name, bucket := range buckets {
+		exactA, _ := exactDeltaAmounts(pool.SqrtPrice, pool.SqrtMaxPrice, bucket.liquidity)
+		_, exactB := exactDeltaAmounts(pool.SqrtMinPrice, pool.SqrtPrice, bucket.liquidity)
+		checkRounding(t, name+" token A", new(big.Int).SetUint64(bucket.amounts.AmountA), exactA, RoundingDown)
+		checkRounding(t, name+" token B", new(big.Int).SetUint64(bucket.amounts.AmountB), exactB, RoundingDown)
+	}
+
+	// Each bucket drops its remainder, so together they hold at most one unit less per bucket than the total
+	sumA := value.Unlocked.AmountA + value.Vested.AmountA + value.PermanentLocked.AmountA
+	if sumA > value.Total.AmountA || value.Total.AmountA-sumA > 2 {
+		t.Fatalf("expected the buckets to add up to the total %d within rounding, got %d", value.Total.AmountA, sumA)
+	}
+
+	if !value.Claimable.FeeTokenA.Equals64(9) {
+		t.Fatalf("expected the pending fee to be claimable, got %s", value.Claimable.FeeTokenA)
+	}
+
+	if _, err := ValuePositionAt(&common.Pool{}, position, 0); err == nil {
+		t.Fatal("expected a pool without a price to be rejected")
+	}
+}
@@ -0,0 +1,140 @@
+package helpers
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+// DefaultDepthLevelsBps are the price bands of a standard depth report, ±1%, ±2% and ±5%
+var DefaultDepthLevelsBps = []uint64{100, 200, 500}
+
+// GetAmountInForSqrtPrice quotes the input amount, fees included, that moves the pool price to targetSqrtPrice.
+// Targets below the current price sell token A, targets above it sell token B.
+// currentPoint is a slot or a unix timestamp depending on the pool's ActivationType.
+func GetAmountInForSqrtPrice(pool *common.Pool, targetSqrtPrice uint128.Uint128, hasReferral bool, currentPoint uint64) (*common.SwapQuote, error) {
+	if targetSqrtPrice.Cmp(pool.SqrtMinPrice) < 0 || targetSqrtPrice.Cmp(pool.SqrtMaxPrice) > 0 {
+		return nil, fmt.Errorf("target sqrt price %s out of pool range [%s, %s]", targetSqrtPrice, pool.SqrtMinPrice, pool.SqrtMaxPrice)
+	}
+
+	aToB := targetSqrtPrice.Cmp(pool.SqrtPrice) < 0
+
+	// Net amount that has to reach the curve, rounded up so the target is reached
+	var netAmountIn *big.Int
+	if aToB {
+		netAmountIn = GetDeltaAmountA(targetSqrtPrice, pool.SqrtPrice, pool.Liquidity, RoundingUp)
+	} else {
+		netAmountIn = GetDeltaAmountB(pool.SqrtPrice, targetSqrtPrice, pool.Liquidity, RoundingUp)
+	}
+	if !netAmountIn.IsUint64() {
+		return nil, fmt.Errorf("input amount %s overflows u64", netAmountIn)
+	}
+
+	tradeDirection := common.TradeDirectionBtoA
+	if aToB {
+		tradeDirection = common.TradeDirectionAtoB
+	}
+	feeMode, err := GetFeeMode(pool.CollectFeeMode, tradeDirection, hasReferral)
+	if err != nil {
+		return nil, err
+	}
+
+	amountIn := netAmountIn.Uint64()
+	if feeMode.FeesOnInput {
+		tradeFeeNumerator, err := GetTotalTradingFee(pool.PoolFees, currentPoint, pool.ActivationPoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get trading fee: %w", err)
+		}
+		amountIn, err = getAmountInIncludingFee(pool.PoolFees, amountIn, tradeFeeNumerator)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return GetSwapQuote(pool, amountIn, aToB, hasReferral, currentPoint)
+}
+
+// getAmountInIncludingFee returns the smallest amount that leaves at least netAmount after the trading fee
+func getAmountInIncludingFee(poolFees common.PoolFeesStruct, netAmount uint64, tradeFeeNumerator uint64) (uint64, error) {
+	if tradeFeeNumerator >= common.FeeDenominator {
+		return 0, fmt.Errorf("invalid trade fee numerator: %d", tradeFeeNumerator)
+	}
+
+	amount := mulDiv(
+		new(big.Int).SetUint64(netAmount),
+		big.NewInt(common.FeeDenominator),
+		new(big.Int).SetUint64(common.FeeDenominator-tradeFeeNumerator),
+		RoundingUp,
+	)
+
+	// The fee itself rounds up, so step forward until the net amount is covered
+	for {
+		if !amount.IsUint64() {
+			return 0, fmt.Errorf("input amount %s overflows u64", amount)
+		}
+		fees, err := GetFeeOnAmount(poolFees, amount.Uint64(), tradeFeeNumerator, false, false)
+		if err != nil {
+			return 0, err
+		}
+		if fees.Amount >= netAmount {
+			return amount.Uint64(), nil
+		}
+		amount.Add(amount, big.NewInt(1))
+	}
+}
+
+// GetSqrtPriceAtPriceChange returns the sqrt price after the price moves by changeBps basis points,
+// up or down, clamped to the pool's price range
+func GetSqrtPriceAtPriceChange(pool *common.Pool, changeBps uint64, up bool) uint128.Uint128 {
+	factor := new(big.Int).SetUint64(common.BasisPointMax)
+	if up {
+		factor.Add(factor, new(big.Int).SetUint64(changeBps))
+	} else {
+		if changeBps >= common.BasisPointMax {
+			return pool.SqrtMinPrice
+		}
+		factor.Sub(factor, new(big.Int).SetUint64(changeBps))
+	}
+
+	// √(P * factor) = √(√P² * factor / BasisPointMax)
+	squared := new(big.Int).Mul(pool.SqrtPrice.Big(), pool.SqrtPrice.Big())
+	squared.Mul(squared, factor)
+	squared.Quo(squared, big.NewInt(common.BasisPointMax))
+	target := toUint128(squared.Sqrt(squared))
+
+	if target.Cmp(pool.SqrtMinPrice) < 0 {
+		return pool.SqrtMinPrice
+	}
+	if target.Cmp(pool.SqrtMaxPrice) > 0 {
+		return pool.SqrtMaxPrice
+	}
+	return target
+}
+
+// GetDepth returns the liquidity available within each price band around the current price.
+// Amounts exclude fees and are clamped to SqrtMinPrice and SqrtMaxPrice.
+func GetDepth(pool *common.Pool, levelsBps []uint64) []common.DepthLevel {
+	if levelsBps == nil {
+		levelsBps = DefaultDepthLevelsBps
+	}
+
+	levels := make([]common.DepthLevel, 0, len(levelsBps))
+	for _, bps := range levelsBps {
+		bidSqrtPrice := GetSqrtPriceAtPriceChange(pool, bps, false)
+		askSqrtPrice := GetSqrtPriceAtPriceChange(pool, bps, true)
+
+		levels = append(levels, common.DepthLevel{
+			ChangeBps:    bps,
+			BidSqrtPrice: bidSqrtPrice,
+			BidAmountA:   toUint64(GetDeltaAmountA(bidSqrtPrice, pool.SqrtPrice, pool.Liquidity, RoundingUp)),
+			BidAmountB:   toUint64(GetDeltaAmountB(bidSqrtPrice, pool.SqrtPrice, pool.Liquidity, RoundingDown)),
+			AskSqrtPrice: askSqrtPrice,
+			AskAmountA:   toUint64(GetDeltaAmountA(pool.SqrtPrice, askSqrtPrice, pool.Liquidity, RoundingDown)),
+			AskAmountB:   toUint64(GetDeltaAmountB(pool.SqrtPrice, askSqrtPrice, pool.Liquidity, RoundingUp)),
+		})
+	}
+
+	return levels
+}
@@ -0,0 +1,132 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+// testSwapPool is a pool at price 1 with a 0.25% base fee over the full price range
+func testSwapPool(collectFeeMode common.CollectFeeMode) *common.Pool {
+	pool := &common.Pool{
+		SqrtPrice:      uint128.From64(1).Lsh(common.ScaleOffset),
+		SqrtMinPrice:   MinSqrtPrice,
+		SqrtMaxPrice:   MaxSqrtPrice,
+		Liquidity:      uint128.From64(1_000_000_000).Lsh(common.ScaleOffset),
+		CollectFeeMode: collectFeeMode,
+	}
+	pool.PoolFees.BaseFee.CliffFeeNumerator = 2_500_000
+	pool.PoolFees.ProtocolFeePercent = 20
+	return pool
+}
+
+// reaches reports whether a swap ending at next has moved the price to target
+func reaches(next uint128.Uint128, target uint128.Uint128, aToB bool) bool {
+	if aToB {
+		return next.Cmp(target) <= 0
+	}
+	return next.Cmp(target) >= 0
+}
+
+func TestGetAmountInForSqrtPriceReachesTarget(t *testing.T) {
+	tests := []struct {
+		name           string
+		collectFeeMode common.CollectFeeMode
+		changeBps      uint64
+		up             bool
+		hasReferral    bool
+	}{
+		// Only B pools charge the fee on the input when selling token B
+		{name: "fees on input, B to A", collectFeeMode: common.CollectFeeModeOnlyB, changeBps: 100, up: true},
+		{name: "fees on input with referral", collectFeeMode: common.CollectFeeModeOnlyB, changeBps: 250, up: true, hasReferral: true},
+		{name: "fees on output, A to B", collectFeeMode: common.CollectFeeModeOnlyB, changeBps: 100, up: false},
+		{name: "both tokens, A to B", collectFeeMode: common.CollectFeeModeBothToken, changeBps: 500, up: false},
+		{name: "both tokens, B to A", collectFeeMode: common.CollectFeeModeBothToken, changeBps: 37, up: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := testSwapPool(tt.collectFeeMode)
+			target := GetSqrtPriceAtPriceChange(pool, tt.changeBps, tt.up)
+			aToB := !tt.up
+
+			quote, err := GetAmountInForSqrtPrice(pool, target, tt.hasReferral, 0)
+			if err != nil {
+				t.Fatalf("failed to quote the amount in: %v", err)
+			}
+
+			swap, err := GetSwapQuote(pool, quote.AmountIn, aToB, tt.hasReferral, 0)
+			if err != nil {
+				t.Fatalf("failed to quote the swap: %v", err)
+			}
+			if !reaches(swap.NextSqrtPrice, target, aToB) {
+				t.Fatalf("expected %d in to reach %s, stopped at %s", quote.AmountIn, target, swap.NextSqrtPrice)
+			}
+
+			// One unit less must fall short, so the quote overshoots by at most one unit
+			short, err := GetSwapQuote(pool, quote.AmountIn-1, aToB, tt.hasReferral, 0)
+			if err != nil {
+				t.Fatalf("failed to quote the swap: %v", err)
+			}
+			if reaches(short.NextSqrtPrice, target, aToB) {
+				t.Fatalf("expected %d in to fall short of %s, reached %s", quote.AmountIn-1, target, short.NextSqrtPrice)
+			}
+		})
+	}
+}
+
+func TestGetAmountInIncludingFee(t *testing.T) {
+	poolFees := testSwapPool(common.CollectFeeModeOnlyB).PoolFees
+
+	for _, netAmount := range []uint64{1, 399, 400, 1_000_003, 1 << 40} {
+		amount, err := getAmountInIncludingFee(poolFees, netAmount, 2_500_000)
+		if err != nil {
+			t.Fatalf("failed to include the fee on %d: %v", netAmount, err)
+		}
+
+		fees, err := GetFeeOnAmount(poolFees, amount, 2_500_000, false, false)
+		if err != nil {
+			t.Fatalf("failed to compute fee: %v", err)
+		}
+		if fees.Amount < netAmount {
+			t.Fatalf("expected %d to leave at least %d after fees, left %d", amount, netAmount, fees.Amount)
+		}
+
+		fees, err = GetFeeOnAmount(poolFees, amount-1, 2_500_000, false, false)
+		if err != nil {
+			t.Fatalf("failed to compute fee: %v", err)
+		}
+		if fees.Amount >= netAmount {
+			t.Fatalf("expected %d to be the smallest amount leaving %d, %d does too", amount, netAmount, amount-1)
+		}
+	}
+
+	if _, err := getAmountInIncludingFee(poolFees, 1, common.FeeDenominator); err == nil {
+		t.Fatal("expected a 100% fee to be rejected")
+	}
+}
+
+func TestGetDepthAmountsReachBands(t *testing.T) {
+	pool := testSwapPool(common.CollectFeeModeBothToken)
+	pool.PoolFees = common.PoolFeesStruct{}
+
+	// Input amounts round up, so a swap can end just past the band and pay out one more unit
+	for _, level := range GetDepth(pool, nil) {
+		bid, err := GetSwapQuote(pool, level.BidAmountA, true, false, 0)
+		if err != nil {
+			t.Fatalf("failed to quote the bid at %d bps: %v", level.ChangeBps, err)
+		}
+		if !reaches(bid.NextSqrtPrice, level.BidSqrtPrice, true) || bid.AmountOut > level.BidAmountB+1 {
+			t.Fatalf("expected %d A to reach the bid at %d bps paying at most %d B, got %+v", level.BidAmountA, level.ChangeBps, level.BidAmountB+1, bid)
+		}
+
+		ask, err := GetSwapQuote(pool, level.AskAmountB, false, false, 0)
+		if err != nil {
+			t.Fatalf("failed to quote the ask at %d bps: %v", level.ChangeBps, err)
+		}
+		if !reaches(ask.NextSqrtPrice, level.AskSqrtPrice, false) || ask.AmountOut > level.AskAmountA+1 {
+			t.Fatalf("expected %d B to reach the ask at %d bps paying at most %d A, got %+v", level.AskAmountB, level.ChangeBps, level.AskAmountA+1, ask)
+		}
+	}
+}
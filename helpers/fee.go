@@ -0,0 +1,256 @@
+package helpers
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+// GetBaseFeeNumerator returns the base fee numerator at the given point, applying the fee scheduler
+func GetBaseFeeNumerator(baseFee common.BaseFeeStruct, currentPoint uint64, activationPoint uint64) (uint64, error) {
+	if baseFee.PeriodFrequency == 0 {
+		return baseFee.CliffFeeNumerator, nil
+	}
+
+	// Before activation the pool charges the fully decayed fee
+	period := uint64(baseFee.NumberOfPeriod)
+	if currentPoint >= activationPoint {
+		period = (currentPoint - activationPoint) / baseFee.PeriodFrequency
+		if period > uint64(baseFee.NumberOfPeriod) {
+			period = uint64(baseFee.NumberOfPeriod)
+		}
+	}
+
+	switch baseFee.FeeSchedulerMode {
+	case common.FeeSchedulerModeLinear:
+		reduction := new(big.Int).Mul(new(big.Int).SetUint64(baseFee.ReductionFactor), new(big.Int).SetUint64(period))
+		if reduction.Cmp(new(big.Int).SetUint64(baseFee.CliffFeeNumerator)) > 0 {
+			return 0, fmt.Errorf("base fee reduction exceeds cliff fee numerator")
+		}
+		return baseFee.CliffFeeNumerator - reduction.Uint64(), nil
+	case common.FeeSchedulerModeExponential:
+		return getFeeInPeriod(baseFee.CliffFeeNumerator, baseFee.ReductionFactor, period)
+	default:
+		return 0, fmt.Errorf("invalid fee scheduler mode: %d", baseFee.FeeSchedulerMode)
+	}
+}
+
+// getFeeInPeriod computes cliffFeeNumerator * (1 - reductionFactor / BasisPointMax) ^ period in Q64.64
+func getFeeInPeriod(cliffFeeNumerator uint64, reductionFactor uint64, period uint64) (uint64, error) {
+	if reductionFactor == 0 {
+		return cliffFeeNumerator, nil
+	}
+
+	bps := new(big.Int).Lsh(new(big.Int).SetUint64(reductionFactor), common.ScaleOffset)
+	bps.Quo(bps, big.NewInt(common.BasisPointMax))
+	base := new(big.Int).Sub(oneQ64, bps)
+	if base.Sign() <= 0 {
+		return 0, fmt.Errorf("reduction factor too large: %d", reductionFactor)
+	}
+
+	// Square-and-multiply with truncation after every step, as the program does
+	result := new(big.Int).Set(oneQ64)
+	squared := base
+	for exp := period; exp > 0; exp >>= 1 {
+		if exp&1 == 1 {
+			result.Mul(result, squared).Rsh(result, common.ScaleOffset)
+		}
+		squared = new(big.Int).Rsh(new(big.Int).Mul(squared, squared), common.ScaleOffset)
+	}
+
+	fee := result.Mul(result, new(big.Int).SetUint64(cliffFeeNumerator))
+	fee.Rsh(fee, common.ScaleOffset)
+	return fee.Uint64(), nil
+}
+
+// IsDynamicFeeEnabled reports whether the pool charges a variable fee on top of the base fee
+func IsDynamicFeeEnabled(dynamicFee common.DynamicFeeStruct) bool {
+	return dynamicFee.Initialized != 0
+}
+
+// GetVariableFee returns the variable fee numerator for the current volatility accumulator
+func GetVariableFee(dynamicFee common.DynamicFeeStruct) uint128.Uint128 {
+	if !IsDynamicFeeEnabled(dynamicFee) {
+		return uint128.Zero
+	}
+
+	// Volatility accumulator, bin step and variable fee control are in basis points,
+	// so the product is scaled by 1e20. Scale it down to 1e9 and round up.
+	vfaBin := new(big.Int).Mul(dynamicFee.VolatilityAccumulator.Big(), big.NewInt(int64(dynamicFee.BinStep)))
+	vFee := new(big.Int).Mul(vfaBin, vfaBin)
+	vFee.Mul(vFee, big.NewInt(int64(dynamicFee.VariableFeeControl)))
+	vFee.Add(vFee, big.NewInt(99_999_999_999))
+	vFee.Quo(vFee, big.NewInt(100_000_000_000))
+
+	return toUint128(vFee)
+}
+
+// GetVariableFeeAt returns the variable fee numerator left behind by a swap at timestamp that moves the
+// price from sqrtPrice to sqrtPriceAfter. The program charges a swap with the accumulator stored by the
+// previous one, so that swap itself pays GetVariableFee(dynamicFee) and the returned fee is charged on
+// the next swap. Use DynamicFeeSimulator to follow a sequence of swaps.
+func GetVariableFeeAt(dynamicFee common.DynamicFeeStruct, sqrtPrice uint128.Uint128, sqrtPriceAfter uint128.Uint128, timestamp uint64) (uint128.Uint128, error) {
+	if !IsDynamicFeeEnabled(dynamicFee) {
+		return uint128.Zero, nil
+	}
+
+	if err := UpdateReferences(&dynamicFee, sqrtPrice, timestamp); err != nil {
+		return uint128.Zero, err
+	}
+	UpdateVolatilityAccumulator(&dynamicFee, sqrtPriceAfter)
+
+	return GetVariableFee(dynamicFee), nil
+}
+
+// GetTotalTradingFee returns the base plus variable fee numerator, capped at MaxFeeNumerator
+func GetTotalTradingFee(poolFees common.PoolFeesStruct, currentPoint uint64, activationPoint uint64) (uint64, error) {
+	baseFeeNumerator, err := GetBaseFeeNumerator(poolFees.BaseFee, currentPoint, activationPoint)
+	if err != nil {
+		return 0, err
+	}
+
+	totalFeeNumerator := GetVariableFee(poolFees.DynamicFee).Big()
+	totalFeeNumerator.Add(totalFeeNumerator, new(big.Int).SetUint64(baseFeeNumerator))
+	if totalFeeNumerator.Cmp(big.NewInt(common.MaxFeeNumerator)) > 0 {
+		return common.MaxFeeNumerator, nil
+	}
+
+	return totalFeeNumerator.Uint64(), nil
+}
+
+// UpdateReferences refreshes the sqrt price and volatility references before a swap
+func UpdateReferences(dynamicFee *common.DynamicFeeStruct, sqrtPrice uint128.Uint128, timestamp uint64) error {
+	if timestamp < dynamicFee.LastUpdateTimestamp {
+		return fmt.Errorf("timestamp %d is before last update %d", timestamp, dynamicFee.LastUpdateTimestamp)
+	}
+
+	elapsed := timestamp - dynamicFee.LastUpdateTimestamp
+
+	// High frequency trades within the filter period keep the current references
+	if elapsed < uint64(dynamicFee.FilterPeriod) {
+		return nil
+	}
+
+	dynamicFee.SqrtPriceReference = sqrtPrice
+
+	if elapsed < uint64(dynamicFee.DecayPeriod) {
+		// Inside the decay window the reference keeps a fraction of the accumulator
+		volatilityReference := new(big.Int).Mul(dynamicFee.VolatilityAccumulator.Big(), big.NewInt(int64(dynamicFee.ReductionFactor)))
+		volatilityReference.Quo(volatilityReference, big.NewInt(common.BasisPointMax))
+		dynamicFee.VolatilityReference = toUint128(volatilityReference)
+	} else {
+		dynamicFee.VolatilityReference = uint128.Zero
+	}
+
+	return nil
+}
+
+// UpdateVolatilityAccumulator recomputes the volatility accumulator after the price moved to sqrtPrice
+func UpdateVolatilityAccumulator(dynamicFee *common.DynamicFeeStruct, sqrtPrice uint128.Uint128) {
+	deltaBinID := GetDeltaBinID(dynamicFee.BinStepU128, sqrtPrice, dynamicFee.SqrtPriceReference)
+
+	volatilityAccumulator := new(big.Int).Mul(deltaBinID.Big(), big.NewInt(common.BasisPointMax))
+	volatilityAccumulator.Add(volatilityAccumulator, dynamicFee.VolatilityReference.Big())
+
+	maxVolatilityAccumulator := big.NewInt(int64(dynamicFee.MaxVolatilityAccumulator))
+	if volatilityAccumulator.Cmp(maxVolatilityAccumulator) > 0 {
+		volatilityAccumulator = maxVolatilityAccumulator
+	}
+
+	dynamicFee.VolatilityAccumulator = toUint128(volatilityAccumulator)
+}
+
+// GetDeltaBinID returns the number of bins crossed between two sqrt prices
+func GetDeltaBinID(binStepU128 uint128.Uint128, sqrtPriceA uint128.Uint128, sqrtPriceB uint128.Uint128) uint128.Uint128 {
+	upper, lower := sqrtPriceA, sqrtPriceB
+	if sqrtPriceB.Cmp(sqrtPriceA) > 0 {
+		upper, lower = sqrtPriceB, sqrtPriceA
+	}
+	if lower.IsZero() || binStepU128.IsZero() {
+		return uint128.Zero
+	}
+
+	priceRatio := shlDiv(upper.Big(), lower.Big(), common.ScaleOffset, RoundingDown)
+	deltaBinID := priceRatio.Sub(priceRatio, oneQ64)
+	deltaBinID.Quo(deltaBinID, binStepU128.Big())
+	deltaBinID.Mul(deltaBinID, big.NewInt(2))
+
+	return toUint128(deltaBinID)
+}
+
+// SimulatedSwap is a hypothetical swap at Timestamp that moves the pool price to SqrtPriceAfter
+type SimulatedSwap struct {
+	Timestamp      uint64
+	SqrtPriceAfter uint128.Uint128
+}
+
+// SimulatedSwapResult holds the variable fee charged on a simulated swap and the state it leaves behind
+type SimulatedSwapResult struct {
+	Timestamp             uint64
+	VariableFeeNumerator  uint128.Uint128
+	VolatilityAccumulator uint128.Uint128
+	VolatilityReference   uint128.Uint128
+}
+
+// DynamicFeeSimulator tracks a copy of a pool's dynamic fee state across hypothetical swaps
+type DynamicFeeSimulator struct {
+	DynamicFee common.DynamicFeeStruct
+	SqrtPrice  uint128.Uint128
+}
+
+// NewDynamicFeeSimulator creates a simulator starting from the pool's current state
+func NewDynamicFeeSimulator(pool *common.Pool) *DynamicFeeSimulator {
+	return &DynamicFeeSimulator{
+		DynamicFee: pool.PoolFees.DynamicFee,
+		SqrtPrice:  pool.SqrtPrice,
+	}
+}
+
+// Swap applies one hypothetical swap, following the program's pre and post swap updates.
+// The returned fee is the one charged on this swap, which uses the accumulator left by the previous swap.
+func (s *DynamicFeeSimulator) Swap(swap SimulatedSwap) (SimulatedSwapResult, error) {
+	if !IsDynamicFeeEnabled(s.DynamicFee) {
+		s.SqrtPrice = swap.SqrtPriceAfter
+		return SimulatedSwapResult{Timestamp: swap.Timestamp}, nil
+	}
+
+	if err := UpdateReferences(&s.DynamicFee, s.SqrtPrice, swap.Timestamp); err != nil {
+		return SimulatedSwapResult{}, err
+	}
+
+	variableFee := GetVariableFee(s.DynamicFee)
+
+	oldSqrtPrice := s.SqrtPrice
+	s.SqrtPrice = swap.SqrtPriceAfter
+	UpdateVolatilityAccumulator(&s.DynamicFee, s.SqrtPrice)
+
+	// The timestamp only moves forward when at least one bin was crossed
+	if !GetDeltaBinID(s.DynamicFee.BinStepU128, oldSqrtPrice, s.SqrtPrice).IsZero() {
+		s.DynamicFee.LastUpdateTimestamp = swap.Timestamp
+	}
+
+	return SimulatedSwapResult{
+		Timestamp:             swap.Timestamp,
+		VariableFeeNumerator:  variableFee,
+		VolatilityAccumulator: s.DynamicFee.VolatilityAccumulator,
+		VolatilityReference:   s.DynamicFee.VolatilityReference,
+	}, nil
+}
+
+// SimulateDynamicFee replays a sequence of hypothetical swaps against the pool's dynamic fee state
+func SimulateDynamicFee(pool *common.Pool, swaps []SimulatedSwap) ([]SimulatedSwapResult, error) {
+	simulator := NewDynamicFeeSimulator(pool)
+
+	results := make([]SimulatedSwapResult, 0, len(swaps))
+	for i, swap := range swaps {
+		result, err := simulator.Swap(swap)
+		if err != nil {
+			return nil, fmt.Errorf("failed to simulate swap %d: %w", i, err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,108 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+func testDynamicFeePool() *common.Pool {
+	sqrtPrice := uint128.From64(1).Lsh(64)
+	pool := &common.Pool{SqrtPrice: sqrtPrice}
+	pool.PoolFees.DynamicFee = common.DynamicFeeStruct{
+		Initialized:              1,
+		MaxVolatilityAccumulator: 350_000,
+		VariableFeeControl:       40_000,
+		BinStep:                  1,
+		FilterPeriod:             10,
+		DecayPeriod:              120,
+		ReductionFactor:          5_000,
+		LastUpdateTimestamp:      1_000,
+		BinStepU128:              uint128.From64(1_844_674_407_370_955),
+		SqrtPriceReference:       sqrtPrice,
+		VolatilityAccumulator:    uint128.From64(200_000),
+	}
+	return pool
+}
+
+func TestGetVariableFeeAtMatchesTheNextSimulatedSwap(t *testing.T) {
+	pool := testDynamicFeePool()
+	sqrtPrice := pool.SqrtPrice
+	sqrtPriceAfter := sqrtPrice.Mul64(105).Div64(100)
+
+	fee, err := GetVariableFeeAt(pool.PoolFees.DynamicFee, sqrtPrice, sqrtPriceAfter, 1_005)
+	if err != nil {
+		t.Fatalf("failed to get variable fee: %v", err)
+	}
+
+	// The first swap pays the stored accumulator, the second one the accumulator the first left behind
+	results, err := SimulateDynamicFee(pool, []SimulatedSwap{
+		{Timestamp: 1_005, SqrtPriceAfter: sqrtPriceAfter},
+		{Timestamp: 1_005, SqrtPriceAfter: sqrtPriceAfter},
+	})
+	if err != nil {
+		t.Fatalf("failed to simulate swaps: %v", err)
+	}
+	if want := GetVariableFee(pool.PoolFees.DynamicFee); !results[0].VariableFeeNumerator.Equals(want) {
+		t.Fatalf("expected the first swap to pay the stored accumulator fee %s, got %s", want, results[0].VariableFeeNumerator)
+	}
+	if !fee.Equals(results[1].VariableFeeNumerator) {
+		t.Fatalf("expected the fee charged on the next swap %s, got %s", results[1].VariableFeeNumerator, fee)
+	}
+	if !fee.Equals(GetVariableFee(common.DynamicFeeStruct{
+		Initialized:           1,
+		VariableFeeControl:    40_000,
+		BinStep:               1,
+		VolatilityAccumulator: uint128.From64(350_000),
+	})) {
+		t.Fatalf("expected a 5%% move to push the accumulator to its maximum, got fee %s", fee)
+	}
+}
+
+func TestGetVariableFeeAtDecays(t *testing.T) {
+	pool := testDynamicFeePool()
+	dynamicFee := pool.PoolFees.DynamicFee
+	sqrtPrice := pool.SqrtPrice
+
+	tests := []struct {
+		name        string
+		timestamp   uint64
+		accumulator uint64
+	}{
+		// Within the filter period the reference is kept, which is zero here
+		{name: "filter period", timestamp: 1_005, accumulator: 0},
+		// Within the decay period the reference keeps half of the accumulator
+		{name: "decay period", timestamp: 1_050, accumulator: 100_000},
+		// Past the decay period the reference is reset
+		{name: "decayed", timestamp: 1_000 + uint64(dynamicFee.DecayPeriod), accumulator: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A swap that crosses no bin leaves the accumulator at the volatility reference
+			fee, err := GetVariableFeeAt(dynamicFee, sqrtPrice, sqrtPrice, tt.timestamp)
+			if err != nil {
+				t.Fatalf("failed to get variable fee: %v", err)
+			}
+
+			expected := dynamicFee
+			expected.VolatilityAccumulator = uint128.From64(tt.accumulator)
+			if want := GetVariableFee(expected); !fee.Equals(want) {
+				t.Fatalf("expected %s, got %s", want, fee)
+			}
+		})
+	}
+
+	decayed, err := GetVariableFeeAt(dynamicFee, sqrtPrice, sqrtPrice, 1_200)
+	if err != nil {
+		t.Fatalf("failed to get variable fee: %v", err)
+	}
+	if current := GetVariableFee(dynamicFee); !decayed.IsZero() || current.IsZero() {
+		t.Fatalf("expected the fee to decay from %s to zero, got %s", current, decayed)
+	}
+
+	if _, err := GetVariableFeeAt(dynamicFee, sqrtPrice, sqrtPrice, 999); err == nil {
+		t.Fatal("expected an error for a timestamp before the last update")
+	}
+}
@@ -0,0 +1,79 @@
+package helpers
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+// GetImpermanentLossFromEntryPrice compares a position with holding the tokens it would have
+// been deposited with at entrySqrtPrice, assuming its liquidity has not changed since entry
+func GetImpermanentLossFromEntryPrice(pool *common.Pool, position *common.PositionState, entrySqrtPrice uint128.Uint128) (*common.ImpermanentLoss, error) {
+	if entrySqrtPrice.Cmp(pool.SqrtMinPrice) < 0 || entrySqrtPrice.Cmp(pool.SqrtMaxPrice) > 0 {
+		return nil, fmt.Errorf("entry sqrt price %s out of pool range", entrySqrtPrice)
+	}
+
+	// Deposits round up, as the program does when liquidity is added
+	liquidity := getPositionTotalLiquidity(position)
+	entryAmounts := common.TokenAmounts{
+		AmountA: toUint64(GetDeltaAmountA(entrySqrtPrice, pool.SqrtMaxPrice, liquidity, RoundingUp)),
+		AmountB: toUint64(GetDeltaAmountB(pool.SqrtMinPrice, entrySqrtPrice, liquidity, RoundingUp)),
+	}
+
+	return GetImpermanentLossFromEntryAmounts(pool, position, entryAmounts)
+}
+
+// GetImpermanentLossFromEntryAmounts compares a position's current value plus its claimed and
+// pending fees with holding the deposited entry amounts
+func GetImpermanentLossFromEntryAmounts(pool *common.Pool, position *common.PositionState, entryAmounts common.TokenAmounts) (*common.ImpermanentLoss, error) {
+	value, err := ValuePosition(pool, position)
+	if err != nil {
+		return nil, err
+	}
+
+	// Raw price of one unit of token A in units of token B
+	price := SqrtPriceToPrice(pool.SqrtPrice, 0, 0)
+
+	valueInB := func(amountA *big.Int, amountB *big.Int) *big.Rat {
+		v := new(big.Rat).Mul(new(big.Rat).SetInt(amountA), price)
+		return v.Add(v, new(big.Rat).SetInt(amountB))
+	}
+
+	holdValue := valueInB(new(big.Int).SetUint64(entryAmounts.AmountA), new(big.Int).SetUint64(entryAmounts.AmountB))
+	positionValue := valueInB(new(big.Int).SetUint64(value.Total.AmountA), new(big.Int).SetUint64(value.Total.AmountB))
+
+	feeA := new(big.Int).SetUint64(position.Metrics.TotalClaimedAFee)
+	feeA.Add(feeA, value.Claimable.FeeTokenA.Big())
+	feeB := new(big.Int).SetUint64(position.Metrics.TotalClaimedBFee)
+	feeB.Add(feeB, value.Claimable.FeeTokenB.Big())
+	feeValue := valueInB(feeA, feeB)
+
+	impermanentLoss := new(big.Rat).Sub(positionValue, holdValue)
+
+	impermanentLossPercent := new(big.Rat)
+	if holdValue.Sign() > 0 {
+		impermanentLossPercent.Quo(impermanentLoss, holdValue)
+		impermanentLossPercent.Mul(impermanentLossPercent, big.NewRat(100, 1))
+	}
+
+	netProfit := new(big.Rat).Add(impermanentLoss, feeValue)
+
+	breakEvenFee := new(big.Rat)
+	if impermanentLoss.Sign() < 0 {
+		breakEvenFee.Neg(impermanentLoss)
+	}
+
+	return &common.ImpermanentLoss{
+		EntryAmounts:           entryAmounts,
+		CurrentPrice:           price,
+		HoldValue:              holdValue,
+		PositionValue:          positionValue,
+		FeeValue:               feeValue,
+		ImpermanentLoss:        impermanentLoss,
+		ImpermanentLossPercent: impermanentLossPercent,
+		NetProfit:              netProfit,
+		BreakEvenFee:           breakEvenFee,
+	}, nil
+}
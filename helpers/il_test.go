@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+// testILPool is a full range pool at sqrtPrice holding a single position
+func testILPool(sqrtPrice uint128.Uint128) (*common.Pool, *common.PositionState) {
+	liquidity := uint128.From64(1_000_000_000_000).Lsh(common.ScaleOffset)
+	pool := &common.Pool{
+		SqrtPrice:    sqrtPrice,
+		SqrtMinPrice: MinSqrtPrice,
+		SqrtMaxPrice: MaxSqrtPrice,
+		Liquidity:    liquidity,
+	}
+	return pool, &common.PositionState{UnlockedLiquidity: liquidity}
+}
+
+func TestGetImpermanentLossFromEntryPrice(t *testing.T) {
+	oneQ64 := uint128.From64(1).Lsh(common.ScaleOffset)
+
+	tests := []struct {
+		name string
+		// sqrtRatio is √r, the square root of the current price over the entry price
+		sqrtRatio *big.Rat
+		// want is 2√r/(1+r) - 1 in percent, the full range reference
+		want *big.Rat
+	}{
+		{name: "price unchanged", sqrtRatio: big.NewRat(1, 1), want: big.NewRat(0, 1)},
+		{name: "price up 4x", sqrtRatio: big.NewRat(2, 1), want: big.NewRat(-20, 1)},
+		{name: "price down 4x", sqrtRatio: big.NewRat(1, 2), want: big.NewRat(-20, 1)},
+		// 2*3/(1+9) - 1 = -40%
+		{name: "price up 9x", sqrtRatio: big.NewRat(3, 1), want: big.NewRat(-40, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := new(big.Int).Mul(oneQ64.Big(), tt.sqrtRatio.Num())
+			current.Quo(current, tt.sqrtRatio.Denom())
+			pool, position := testILPool(uint128.FromBig(current))
+
+			loss, err := GetImpermanentLossFromEntryPrice(pool, position, oneQ64)
+			if err != nil {
+				t.Fatalf("failed to compute impermanent loss: %v", err)
+			}
+
+			// The price bounds sit far enough out for the full range formula to hold to within 0.0001%
+			diff := new(big.Rat).Sub(loss.ImpermanentLossPercent, tt.want)
+			if diff.Abs(diff).Cmp(big.NewRat(1, 1_000_000)) > 0 {
+				t.Fatalf("expected %s%%, got %s%%", tt.want.FloatString(4), loss.ImpermanentLossPercent.FloatString(8))
+			}
+			if loss.ImpermanentLoss.Sign() > 0 {
+				t.Fatalf("expected the position to be worth no more than holding, got %s", loss.ImpermanentLoss.FloatString(4))
+			}
+		})
+	}
+
+	pool, position := testILPool(oneQ64)
+	if _, err := GetImpermanentLossFromEntryPrice(pool, position, MinSqrtPrice.Sub64(1)); err == nil {
+		t.Fatal("expected an entry price outside the pool range to be rejected")
+	}
+}
+
+func TestGetImpermanentLossFromEntryAmounts(t *testing.T) {
+	oneQ64 := uint128.From64(1).Lsh(common.ScaleOffset)
+	pool, position := testILPool(oneQ64.Mul64(2))
+	position.Metrics.TotalClaimedAFee = 100
+	position.FeeBPending = 50
+
+	value, err := ValuePositionAt(pool, position, 0)
+	if err != nil {
+		t.Fatalf("failed to value position: %v", err)
+	}
+
+	// Entry amounts worth 1_000 less than the position at the current price of 4
+	entry := common.TokenAmounts{AmountA: value.Total.AmountA, AmountB: value.Total.AmountB - 1_000}
+
+	loss, err := GetImpermanentLossFromEntryAmounts(pool, position, entry)
+	if err != nil {
+		t.Fatalf("failed to compute impermanent loss: %v", err)
+	}
+
+	if loss.CurrentPrice.Cmp(big.NewRat(4, 1)) != 0 {
+		t.Fatalf("expected a price of 4, got %s", loss.CurrentPrice.RatString())
+	}
+	if loss.ImpermanentLoss.Cmp(big.NewRat(1_000, 1)) != 0 {
+		t.Fatalf("expected a gain of 1000 over holding, got %s", loss.ImpermanentLoss.RatString())
+	}
+	// 100 A at 4 plus 50 B
+	if loss.FeeValue.Cmp(big.NewRat(450, 1)) != 0 {
+		t.Fatalf("expected fees worth 450, got %s", loss.FeeValue.RatString())
+	}
+	if loss.NetProfit.Cmp(big.NewRat(1_450, 1)) != 0 || loss.BreakEvenFee.Sign() != 0 {
+		t.Fatalf("expected a net profit of 1450 and no break-even fee, got %s and %s", loss.NetProfit.RatString(), loss.BreakEvenFee.RatString())
+	}
+
+	// A loss has to be made up by fees
+	entry.AmountB = value.Total.AmountB + 2_000
+	if loss, err = GetImpermanentLossFromEntryAmounts(pool, position, entry); err != nil {
+		t.Fatalf("failed to compute impermanent loss: %v", err)
+	}
+	if loss.BreakEvenFee.Cmp(big.NewRat(2_000, 1)) != 0 || loss.NetProfit.Cmp(big.NewRat(-1_550, 1)) != 0 {
+		t.Fatalf("expected a break-even fee of 2000 and a net loss of 1550, got %s and %s", loss.BreakEvenFee.RatString(), loss.NetProfit.RatString())
+	}
+}
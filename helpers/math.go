@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"math/big"
+
+	"lukechampine.com/uint128"
+)
+
+// Rounding selects the rounding direction of integer division
+type Rounding int
+
+const (
+	RoundingDown Rounding = iota
+	RoundingUp
+)
+
+// oneQ64 is 1.0 in Q64.64 fixed point
+var oneQ64 = new(big.Int).Lsh(big.NewInt(1), 64)
+
+// mulDiv computes x * y / denominator with the given rounding
+func mulDiv(x, y, denominator *big.Int, rounding Rounding) *big.Int {
+	prod := new(big.Int).Mul(x, y)
+	quo, rem := new(big.Int).QuoRem(prod, denominator, new(big.Int))
+	if rounding == RoundingUp && rem.Sign() != 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	return quo
+}
+
+// shlDiv computes (x << offset) / y with the given rounding
+func shlDiv(x, y *big.Int, offset uint, rounding Rounding) *big.Int {
+	return mulDiv(x, new(big.Int).Lsh(big.NewInt(1), offset), y, rounding)
+}
+
+// toUint128 converts x to a Uint128, saturating at the maximum value
+func toUint128(x *big.Int) uint128.Uint128 {
+	if x.Sign() <= 0 {
+		return uint128.Zero
+	}
+	if x.BitLen() > 128 {
+		return uint128.Max
+	}
+	return uint128.FromBig(new(big.Int).Set(x))
+}
+
+// bigFromLE decodes a little-endian unsigned integer such as the U256 byte arrays stored on chain
+func bigFromLE(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+// ValuePosition returns the underlying token amounts of each liquidity bucket of a position,
+// plus its claimable fees and rewards, at the pool's current price
+func ValuePosition(pool *common.Pool, position *common.PositionState) (*common.PositionValue, error) {
+	return ValuePositionAt(pool, position, uint64(time.Now().Unix()))
+}
+
+// ValuePositionAt is ValuePosition with rewards accrued up to the given unix timestamp
+func ValuePositionAt(pool *common.Pool, position *common.PositionState, currentTime uint64) (*common.PositionValue, error) {
+	if pool.SqrtPrice.IsZero() {
+		return nil, fmt.Errorf("pool has no price")
+	}
+
+	// Amounts are rounded down, as the program does when liquidity is removed
+	unlocked := getTokenAmounts(pool, position.UnlockedLiquidity)
+	vested := getTokenAmounts(pool, position.VestedLiquidity)
+	permanentLocked := getTokenAmounts(pool, position.PermanentLockedLiquidity)
+
+	claimable, err := GetUnclaimRewardAt(pool, position, currentTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unclaimed rewards: %w", err)
+	}
+
+	return &common.PositionValue{
+		Unlocked:        unlocked,
+		Vested:          vested,
+		PermanentLocked: permanentLocked,
+		Total:           getTokenAmounts(pool, getPositionTotalLiquidity(position)),
+		Claimable:       *claimable,
+	}, nil
+}
+
+// ValuePositionWithDecimals is ValuePosition with the position's total value, including
+// claimable fees, expressed in both tokens. Rewards are excluded as they are paid in other mints.
+func ValuePositionWithDecimals(
+	pool *common.Pool,
+	position *common.PositionState,
+	tokenADecimals uint8,
+	tokenBDecimals uint8,
+) (*common.PositionValue, error) {
+	value, err := ValuePosition(pool, position)
+	if err != nil {
+		return nil, err
+	}
+
+	totalA := new(big.Int).SetUint64(value.Total.AmountA)
+	totalA.Add(totalA, value.Claimable.FeeTokenA.Big())
+	totalB := new(big.Int).SetUint64(value.Total.AmountB)
+	totalB.Add(totalB, value.Claimable.FeeTokenB.Big())
+
+	humanA := new(big.Rat).SetFrac(totalA, pow10(int(tokenADecimals)))
+	humanB := new(big.Rat).SetFrac(totalB, pow10(int(tokenBDecimals)))
+	price := GetPoolPrice(pool, tokenADecimals, tokenBDecimals)
+
+	// Value in token B is A * price + B, value in token A is that divided by the price
+	value.TotalInTokenB = new(big.Rat).Add(new(big.Rat).Mul(humanA, price), humanB)
+	if price.Sign() > 0 {
+		value.TotalInTokenA = new(big.Rat).Quo(value.TotalInTokenB, price)
+	}
+
+	return value, nil
+}
+
+// getTokenAmounts returns the token amounts backing liquidity, rounded down
+func getTokenAmounts(pool *common.Pool, liquidity uint128.Uint128) common.TokenAmounts {
+	amountA, amountB := GetAmountsForLiquidity(pool, liquidity, RoundingDown)
+	return common.TokenAmounts{
+		AmountA: toUint64(amountA),
+		AmountB: toUint64(amountB),
+	}
+}
+
+// SumPositionLiquidity returns the total liquidity of a set of positions, which for all
+// positions of a pool should equal Pool.Liquidity
+func SumPositionLiquidity(positions []common.PositionResult) uint128.Uint128 {
+	total := uint128.Zero
+	for i := range positions {
+		total = total.Add(getPositionTotalLiquidity(&positions[i].PositionState))
+	}
+	return total
+}
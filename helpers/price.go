@@ -0,0 +1,127 @@
+package helpers
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+// Sqrt price bounds accepted by the program
+var (
+	MinSqrtPrice = uint128.From64(4295048016)
+	MaxSqrtPrice = uint128.New(9537527425331189659, 4294886577) // 79226673521066979257578248091
+)
+
+// pow10 returns 10^n as a big.Int
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// decimalsScale returns 10^(tokenADecimals - tokenBDecimals) as a rational
+func decimalsScale(tokenADecimals uint8, tokenBDecimals uint8) *big.Rat {
+	if tokenADecimals >= tokenBDecimals {
+		return new(big.Rat).SetInt(pow10(int(tokenADecimals - tokenBDecimals)))
+	}
+	return new(big.Rat).SetFrac(big.NewInt(1), pow10(int(tokenBDecimals-tokenADecimals)))
+}
+
+// SqrtPriceToPrice converts a Q64.64 sqrt price into the exact price of token A in token B,
+// adjusted for the token decimals
+func SqrtPriceToPrice(sqrtPrice uint128.Uint128, tokenADecimals uint8, tokenBDecimals uint8) *big.Rat {
+	sqrt := sqrtPrice.Big()
+	numerator := new(big.Int).Mul(sqrt, sqrt)
+	denominator := new(big.Int).Lsh(big.NewInt(1), 2*common.ScaleOffset)
+
+	price := new(big.Rat).SetFrac(numerator, denominator)
+	return price.Mul(price, decimalsScale(tokenADecimals, tokenBDecimals))
+}
+
+// PriceToSqrtPrice converts a decimal adjusted price of token A in token B into a Q64.64 sqrt price.
+// The result is rounded in the given direction and must fall inside the program's sqrt price bounds.
+func PriceToSqrtPrice(price *big.Rat, tokenADecimals uint8, tokenBDecimals uint8, rounding Rounding) (uint128.Uint128, error) {
+	if price.Sign() <= 0 {
+		return uint128.Zero, fmt.Errorf("price must be positive")
+	}
+
+	// Raw price in smallest units, scaled by 2^128 so its square root is in Q64.64
+	raw := new(big.Rat).Quo(price, decimalsScale(tokenADecimals, tokenBDecimals))
+	raw.Mul(raw, new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), 2*common.ScaleOffset)))
+
+	floor := new(big.Int).Quo(raw.Num(), raw.Denom())
+	sqrtPrice := new(big.Int).Sqrt(floor)
+
+	if rounding == RoundingUp {
+		squared := new(big.Rat).SetInt(new(big.Int).Mul(sqrtPrice, sqrtPrice))
+		if squared.Cmp(raw) != 0 {
+			sqrtPrice.Add(sqrtPrice, big.NewInt(1))
+		}
+	}
+
+	if sqrtPrice.Cmp(MinSqrtPrice.Big()) < 0 || sqrtPrice.Cmp(MaxSqrtPrice.Big()) > 0 {
+		return uint128.Zero, fmt.Errorf("sqrt price %s out of range [%s, %s]", sqrtPrice, MinSqrtPrice, MaxSqrtPrice)
+	}
+
+	return uint128.FromBig(sqrtPrice), nil
+}
+
+// ParsePrice parses a decimal string such as "142.0375" into an exact rational price
+func ParsePrice(s string) (*big.Rat, error) {
+	price, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return nil, fmt.Errorf("invalid price: %q", s)
+	}
+	return price, nil
+}
+
+// GetPoolPrice returns the current price of token A in token B
+func GetPoolPrice(pool *common.Pool, tokenADecimals uint8, tokenBDecimals uint8) *big.Rat {
+	return SqrtPriceToPrice(pool.SqrtPrice, tokenADecimals, tokenBDecimals)
+}
+
+// GetPoolPriceRange returns the minimum and maximum prices the pool can trade at
+func GetPoolPriceRange(pool *common.Pool, tokenADecimals uint8, tokenBDecimals uint8) (*big.Rat, *big.Rat) {
+	return SqrtPriceToPrice(pool.SqrtMinPrice, tokenADecimals, tokenBDecimals),
+		SqrtPriceToPrice(pool.SqrtMaxPrice, tokenADecimals, tokenBDecimals)
+}
+
+// FormatPrice formats a price as a decimal string with the given number of significant digits
+func FormatPrice(price *big.Rat, significantDigits int) string {
+	if price.Sign() == 0 {
+		return "0"
+	}
+	if significantDigits < 1 {
+		significantDigits = 1
+	}
+
+	abs := new(big.Rat).Abs(price)
+	one := big.NewRat(1, 1)
+
+	// Digits after the point needed to show the requested significant digits
+	decimals := significantDigits
+	if abs.Cmp(one) >= 0 {
+		integerDigits := len(new(big.Int).Quo(abs.Num(), abs.Denom()).String())
+		decimals = significantDigits - integerDigits
+		if decimals < 0 {
+			decimals = 0
+		}
+	} else {
+		for scaled := new(big.Rat).Mul(abs, big.NewRat(10, 1)); scaled.Cmp(one) < 0; scaled.Mul(scaled, big.NewRat(10, 1)) {
+			decimals++
+		}
+	}
+
+	formatted := price.FloatString(decimals)
+	if strings.Contains(formatted, ".") {
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+	}
+	return formatted
+}
+
+// FormatPoolPriceRange formats the pool's SqrtMinPrice and SqrtMaxPrice as a human price range
+func FormatPoolPriceRange(pool *common.Pool, tokenADecimals uint8, tokenBDecimals uint8, significantDigits int) string {
+	minPrice, maxPrice := GetPoolPriceRange(pool, tokenADecimals, tokenBDecimals)
+	return fmt.Sprintf("%s - %s", FormatPrice(minPrice, significantDigits), FormatPrice(maxPrice, significantDigits))
+}
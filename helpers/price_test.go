@@ -0,0 +1,155 @@
+package helpers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+func TestSqrtPriceToPriceDecimals(t *testing.T) {
+	oneQ64 := uint128.From64(1).Lsh(common.ScaleOffset)
+
+	tests := []struct {
+		name           string
+		sqrtPrice      uint128.Uint128
+		tokenADecimals uint8
+		tokenBDecimals uint8
+		want           *big.Rat
+	}{
+		{name: "same decimals", sqrtPrice: oneQ64, tokenADecimals: 6, tokenBDecimals: 6, want: big.NewRat(1, 1)},
+		// One smallest unit of a 9 decimals token is worth a thousand times less than one of a 6 decimals token
+		{name: "9/6", sqrtPrice: oneQ64, tokenADecimals: 9, tokenBDecimals: 6, want: big.NewRat(1_000, 1)},
+		{name: "6/9", sqrtPrice: oneQ64, tokenADecimals: 6, tokenBDecimals: 9, want: big.NewRat(1, 1_000)},
+		{name: "squared", sqrtPrice: oneQ64.Mul64(3).Div64(2), tokenADecimals: 9, tokenBDecimals: 6, want: big.NewRat(2_250, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := SqrtPriceToPrice(tt.sqrtPrice, tt.tokenADecimals, tt.tokenBDecimals)
+			if price.Cmp(tt.want) != 0 {
+				t.Fatalf("expected %s, got %s", tt.want.RatString(), price.RatString())
+			}
+
+			for _, rounding := range []Rounding{RoundingDown, RoundingUp} {
+				sqrtPrice, err := PriceToSqrtPrice(tt.want, tt.tokenADecimals, tt.tokenBDecimals, rounding)
+				if err != nil {
+					t.Fatalf("failed to convert price: %v", err)
+				}
+				if !sqrtPrice.Equals(tt.sqrtPrice) {
+					t.Fatalf("expected %s, got %s", tt.sqrtPrice, sqrtPrice)
+				}
+			}
+		})
+	}
+}
+
+func TestPriceToSqrtPriceRoundTrip(t *testing.T) {
+	sqrtPrices := []uint128.Uint128{
+		MinSqrtPrice,
+		MinSqrtPrice.Add64(1),
+		uint128.From64(1).Lsh(common.ScaleOffset).Add64(12_345),
+		MaxSqrtPrice.Sub64(1),
+		MaxSqrtPrice,
+	}
+
+	for _, sqrtPrice := range sqrtPrices {
+		price := SqrtPriceToPrice(sqrtPrice, 9, 6)
+		for _, rounding := range []Rounding{RoundingDown, RoundingUp} {
+			got, err := PriceToSqrtPrice(price, 9, 6, rounding)
+			if err != nil {
+				t.Fatalf("failed to convert the price of %s back: %v", sqrtPrice, err)
+			}
+			if !got.Equals(sqrtPrice) {
+				t.Fatalf("expected %s back, got %s", sqrtPrice, got)
+			}
+		}
+	}
+}
+
+func TestPriceToSqrtPriceBounds(t *testing.T) {
+	below := SqrtPriceToPrice(MinSqrtPrice.Sub64(1), 6, 6)
+	if _, err := PriceToSqrtPrice(below, 6, 6, RoundingDown); err == nil {
+		t.Fatal("expected a price below the minimum sqrt price to be rejected")
+	}
+
+	above := SqrtPriceToPrice(MaxSqrtPrice.Add64(1), 6, 6)
+	if _, err := PriceToSqrtPrice(above, 6, 6, RoundingUp); err == nil {
+		t.Fatal("expected a price above the maximum sqrt price to be rejected")
+	}
+
+	// Just below the minimum rounds up into range, but not down
+	smallest := new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), 2*common.ScaleOffset))
+	justBelow := new(big.Rat).Sub(SqrtPriceToPrice(MinSqrtPrice, 6, 6), smallest)
+	if sqrtPrice, err := PriceToSqrtPrice(justBelow, 6, 6, RoundingUp); err != nil || !sqrtPrice.Equals(MinSqrtPrice) {
+		t.Fatalf("expected rounding up to reach the minimum, got %s, %v", sqrtPrice, err)
+	}
+	if _, err := PriceToSqrtPrice(justBelow, 6, 6, RoundingDown); err == nil {
+		t.Fatal("expected rounding down to fall below the minimum")
+	}
+
+	for _, price := range []*big.Rat{big.NewRat(0, 1), big.NewRat(-1, 1)} {
+		if _, err := PriceToSqrtPrice(price, 6, 6, RoundingDown); err == nil {
+			t.Fatalf("expected price %s to be rejected", price.RatString())
+		}
+	}
+}
+
+func TestPriceToSqrtPriceRounding(t *testing.T) {
+	price := big.NewRat(2, 1)
+
+	down, err := PriceToSqrtPrice(price, 6, 6, RoundingDown)
+	if err != nil {
+		t.Fatalf("failed to convert price: %v", err)
+	}
+	up, err := PriceToSqrtPrice(price, 6, 6, RoundingUp)
+	if err != nil {
+		t.Fatalf("failed to convert price: %v", err)
+	}
+
+	if !up.Equals(down.Add64(1)) {
+		t.Fatalf("expected rounding up to add one to %s, got %s", down, up)
+	}
+	if SqrtPriceToPrice(down, 6, 6).Cmp(price) >= 0 {
+		t.Fatalf("expected the rounded down sqrt price to be below the price")
+	}
+	if SqrtPriceToPrice(up, 6, 6).Cmp(price) <= 0 {
+		t.Fatalf("expected the rounded up sqrt price to be above the price")
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		input string
+		want  *big.Rat
+	}{
+		{input: "142.0375", want: big.NewRat(1_420_375, 10_000)},
+		{input: " 0.000001 ", want: big.NewRat(1, 1_000_000)},
+		{input: "1e3", want: big.NewRat(1_000, 1)},
+		{input: "3/4", want: big.NewRat(3, 4)},
+	}
+
+	for _, tt := range tests {
+		price, err := ParsePrice(tt.input)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", tt.input, err)
+		}
+		if price.Cmp(tt.want) != 0 {
+			t.Fatalf("expected %q to parse as %s, got %s", tt.input, tt.want.RatString(), price.RatString())
+		}
+	}
+
+	for _, input := range []string{"", "abc", "1.2.3"} {
+		if _, err := ParsePrice(input); err == nil {
+			t.Fatalf("expected %q to be rejected", input)
+		}
+	}
+
+	// A parsed price converts exactly, without float rounding on the way
+	price, _ := ParsePrice("0.25")
+	sqrtPrice, err := PriceToSqrtPrice(price, 6, 6, RoundingDown)
+	if err != nil || !sqrtPrice.Equals(uint128.From64(1).Lsh(common.ScaleOffset-1)) {
+		t.Fatalf("expected 0.25 to convert to a sqrt price of 0.5, got %s, %v", sqrtPrice, err)
+	}
+}
@@ -0,0 +1,99 @@
+package helpers
+
+import (
+	"math/big"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+// bigToLE32 encodes x as the 32 byte little-endian U256 layout used on chain
+func bigToLE32(x *big.Int) [32]uint8 {
+	var out [32]uint8
+	be := x.FillBytes(make([]byte, 32))
+	for i := range be {
+		out[31-i] = be[i]
+	}
+	return out
+}
+
+// GetRewardSecondsElapsed returns the seconds of reward emission between the last update and currentTime
+func GetRewardSecondsElapsed(rewardInfo common.RewardInfo, currentTime uint64) uint64 {
+	lastTimeRewardApplicable := currentTime
+	if rewardInfo.RewardDurationEnd < lastTimeRewardApplicable {
+		lastTimeRewardApplicable = rewardInfo.RewardDurationEnd
+	}
+	if lastTimeRewardApplicable <= rewardInfo.LastUpdateTime {
+		return 0
+	}
+	return lastTimeRewardApplicable - rewardInfo.LastUpdateTime
+}
+
+// UpdatePoolReward advances a pool reward to currentTime the way the program does before any position update.
+// Emissions while the pool has no liquidity are not distributed and are tracked as empty liquidity seconds instead.
+func UpdatePoolReward(rewardInfo *common.RewardInfo, liquidity uint128.Uint128, currentTime uint64) {
+	if rewardInfo.Initialized == 0 {
+		return
+	}
+
+	timePeriod := GetRewardSecondsElapsed(*rewardInfo, currentTime)
+
+	if !liquidity.IsZero() {
+		// reward_per_token_stored += time * reward_rate << LIQUIDITY_SCALE / liquidity
+		totalReward := new(big.Int).Mul(new(big.Int).SetUint64(timePeriod), rewardInfo.RewardRate.Big())
+		delta := shlDiv(totalReward, liquidity.Big(), common.LIQUIDITY_SCALE, RoundingDown)
+
+		rewardPerTokenStored := bigFromLE(rewardInfo.RewardPerTokenStored[:])
+		rewardPerTokenStored.Add(rewardPerTokenStored, delta)
+		rewardInfo.RewardPerTokenStored = bigToLE32(rewardPerTokenStored)
+	} else {
+		rewardInfo.CumulativeSecondsWithEmptyLiquidity += timePeriod
+	}
+
+	// Never move backwards, and never past the end of the reward duration
+	lastUpdateTime := currentTime
+	if rewardInfo.RewardDurationEnd < lastUpdateTime {
+		lastUpdateTime = rewardInfo.RewardDurationEnd
+	}
+	if lastUpdateTime > rewardInfo.LastUpdateTime {
+		rewardInfo.LastUpdateTime = lastUpdateTime
+	}
+}
+
+// GetPositionRewardAt returns the total pending reward of a position for one pool reward at currentTime
+func GetPositionRewardAt(
+	poolState *common.Pool,
+	positionState *common.PositionState,
+	rewardIndex int,
+	currentTime uint64,
+) uint128.Uint128 {
+	userRewardInfo := positionState.RewardInfos[rewardIndex]
+	pending := new(big.Int).SetUint64(userRewardInfo.RewardPendings)
+
+	rewardInfo := poolState.RewardInfos[rewardIndex]
+	if rewardInfo.Initialized == 0 {
+		return toUint128(pending)
+	}
+
+	UpdatePoolReward(&rewardInfo, poolState.Liquidity, currentTime)
+
+	rewardPerTokenStored := bigFromLE(rewardInfo.RewardPerTokenStored[:])
+	checkpoint := bigFromLE(userRewardInfo.RewardPerTokenCheckpoint[:])
+	if rewardPerTokenStored.Cmp(checkpoint) <= 0 {
+		return toUint128(pending)
+	}
+
+	// reward = liquidity * (reward_per_token_stored - checkpoint) >> (LIQUIDITY_SCALE + REWARD_RATE_SCALE)
+	delta := new(big.Int).Sub(rewardPerTokenStored, checkpoint)
+	newReward := delta.Mul(delta, getPositionTotalLiquidity(positionState).Big())
+	newReward.Rsh(newReward, common.LIQUIDITY_SCALE+common.REWARD_RATE_SCALE)
+
+	return toUint128(pending.Add(pending, newReward))
+}
+
+// getPositionTotalLiquidity returns the unlocked, vested and permanently locked liquidity of a position
+func getPositionTotalLiquidity(positionState *common.PositionState) uint128.Uint128 {
+	return positionState.UnlockedLiquidity.
+		Add(positionState.VestedLiquidity).
+		Add(positionState.PermanentLockedLiquidity)
+}
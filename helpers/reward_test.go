@@ -0,0 +1,153 @@
+package helpers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+	"lukechampine.com/uint128"
+)
+
+// rewardPerToken encodes the reward per token stored for tokens earned per Q64 unit of liquidity,
+// which carries the Q64 reward rate on top of LIQUIDITY_SCALE
+func rewardPerToken(tokens int64) [32]uint8 {
+	return bigToLE32(new(big.Int).Lsh(big.NewInt(tokens), common.LIQUIDITY_SCALE+common.REWARD_RATE_SCALE-64))
+}
+
+// feePerLiquidity encodes the fee per liquidity for tokens earned per Q64 unit of liquidity
+func feePerLiquidity(tokens int64) [32]uint8 {
+	return bigToLE32(new(big.Int).Lsh(big.NewInt(tokens), common.LIQUIDITY_SCALE-64))
+}
+
+// testRewardPool emits one token per second from 1_000 to 1_100 to a pool holding Q64 liquidity of 1
+func testRewardPool(liquidity uint128.Uint128) *common.Pool {
+	pool := &common.Pool{Liquidity: liquidity}
+	pool.RewardInfos[0] = common.RewardInfo{
+		Initialized:       1,
+		RewardDurationEnd: 1_100,
+		RewardRate:        uint128.From64(1).Lsh(common.REWARD_RATE_SCALE),
+		LastUpdateTime:    1_000,
+	}
+	return pool
+}
+
+func TestUpdatePoolRewardClampsAtDurationEnd(t *testing.T) {
+	oneQ64 := uint128.From64(1).Lsh(64)
+	rewardInfo := testRewardPool(oneQ64).RewardInfos[0]
+
+	UpdatePoolReward(&rewardInfo, oneQ64, 5_000)
+
+	if rewardInfo.RewardPerTokenStored != rewardPerToken(100) {
+		t.Fatalf("expected 100 tokens per liquidity, got %s", bigFromLE(rewardInfo.RewardPerTokenStored[:]))
+	}
+	if rewardInfo.LastUpdateTime != 1_100 {
+		t.Fatalf("expected the update time clamped to 1100, got %d", rewardInfo.LastUpdateTime)
+	}
+
+	// Once past the end nothing more accrues
+	UpdatePoolReward(&rewardInfo, oneQ64, 6_000)
+	if rewardInfo.RewardPerTokenStored != rewardPerToken(100) || rewardInfo.LastUpdateTime != 1_100 {
+		t.Fatalf("expected no accrual after the end, got %s at %d", bigFromLE(rewardInfo.RewardPerTokenStored[:]), rewardInfo.LastUpdateTime)
+	}
+}
+
+func TestUpdatePoolRewardWithEmptyLiquidity(t *testing.T) {
+	rewardInfo := testRewardPool(uint128.Zero).RewardInfos[0]
+	rewardInfo.CumulativeSecondsWithEmptyLiquidity = 5
+
+	UpdatePoolReward(&rewardInfo, uint128.Zero, 1_040)
+
+	if rewardInfo.RewardPerTokenStored != ([32]uint8{}) {
+		t.Fatalf("expected nothing distributed, got %s", bigFromLE(rewardInfo.RewardPerTokenStored[:]))
+	}
+	if rewardInfo.CumulativeSecondsWithEmptyLiquidity != 45 {
+		t.Fatalf("expected 40 more seconds with empty liquidity, got %d", rewardInfo.CumulativeSecondsWithEmptyLiquidity)
+	}
+	if rewardInfo.LastUpdateTime != 1_040 {
+		t.Fatalf("expected the update time to advance to 1040, got %d", rewardInfo.LastUpdateTime)
+	}
+}
+
+func TestUpdatePoolRewardUninitialized(t *testing.T) {
+	rewardInfo := common.RewardInfo{LastUpdateTime: 1_000, RewardDurationEnd: 2_000}
+
+	UpdatePoolReward(&rewardInfo, uint128.From64(1), 1_500)
+	if rewardInfo.LastUpdateTime != 1_000 {
+		t.Fatalf("expected an uninitialized reward to be left alone, got %+v", rewardInfo)
+	}
+}
+
+func TestGetPositionRewardAt(t *testing.T) {
+	oneQ64 := uint128.From64(1).Lsh(64)
+
+	tests := []struct {
+		name       string
+		liquidity  uint128.Uint128
+		stored     int64
+		checkpoint int64
+		pending    uint64
+		time       uint64
+		want       uint64
+	}{
+		{name: "whole pool", liquidity: oneQ64, time: 1_050, want: 50},
+		{name: "half of the pool", liquidity: oneQ64.Rsh(1), time: 1_100, want: 50},
+		{name: "clamped at the end", liquidity: oneQ64, time: 9_999, want: 100},
+		{name: "checkpoint subtracted", liquidity: oneQ64, stored: 60, checkpoint: 20, pending: 7, time: 1_100, want: 147},
+		{name: "checkpoint ahead of the pool", liquidity: oneQ64, stored: 10, checkpoint: 500, pending: 7, time: 1_100, want: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := testRewardPool(oneQ64)
+			pool.RewardInfos[0].RewardPerTokenStored = rewardPerToken(tt.stored)
+
+			position := &common.PositionState{UnlockedLiquidity: tt.liquidity}
+			position.RewardInfos[0] = common.UserRewardInfo{
+				RewardPerTokenCheckpoint: rewardPerToken(tt.checkpoint),
+				RewardPendings:           tt.pending,
+			}
+
+			reward := GetPositionRewardAt(pool, position, 0, tt.time)
+			if !reward.Equals64(tt.want) {
+				t.Fatalf("expected %d, got %s", tt.want, reward)
+			}
+			if pool.RewardInfos[0].LastUpdateTime != 1_000 {
+				t.Fatal("expected the pool state to be left unchanged")
+			}
+		})
+	}
+}
+
+func TestGetUnclaimRewardAt(t *testing.T) {
+	oneQ64 := uint128.From64(1).Lsh(64)
+	pool := testRewardPool(oneQ64)
+	pool.FeeAPerLiquidity = feePerLiquidity(30)
+	pool.FeeBPerLiquidity = feePerLiquidity(12)
+
+	// Vested and permanently locked liquidity earn alongside the unlocked liquidity
+	position := &common.PositionState{
+		UnlockedLiquidity:        oneQ64.Rsh(1),
+		VestedLiquidity:          oneQ64.Rsh(2),
+		PermanentLockedLiquidity: oneQ64.Rsh(2),
+		FeeAPerTokenCheckpoint:   feePerLiquidity(10),
+		FeeBPerTokenCheckpoint:   feePerLiquidity(12),
+		FeeAPending:              3,
+		FeeBPending:              4,
+	}
+
+	unclaim, err := GetUnclaimRewardAt(pool, position, 1_025)
+	if err != nil {
+		t.Fatalf("failed to get unclaimed reward: %v", err)
+	}
+	if !unclaim.FeeTokenA.Equals64(23) || !unclaim.FeeTokenB.Equals64(4) {
+		t.Fatalf("expected fees of 23 and 4, got %s and %s", unclaim.FeeTokenA, unclaim.FeeTokenB)
+	}
+	if len(unclaim.Rewards) != 2 || !unclaim.Rewards[0].Equals64(25) || !unclaim.Rewards[1].IsZero() {
+		t.Fatalf("expected rewards of 25 and 0, got %v", unclaim.Rewards)
+	}
+
+	pool.CollectFeeMode = common.CollectFeeModeOnlyB
+	if unclaim, err = GetUnclaimRewardAt(pool, position, 1_025); err != nil || !unclaim.FeeTokenA.IsZero() {
+		t.Fatalf("expected no token A fees when collecting in token B only, got %v, %v", unclaim, err)
+	}
+}
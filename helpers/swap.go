@@ -0,0 +1,183 @@
+package helpers
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"lukechampine.com/uint128"
+)
+
+// GetFeeMode returns which token fees are charged in, and whether they are taken from the input or the output
+func GetFeeMode(collectFeeMode common.CollectFeeMode, tradeDirection common.TradeDirection, hasReferral bool) (common.FeeMode, error) {
+	if err := collectFeeMode.Validate(); err != nil {
+		return common.FeeMode{}, err
+	}
+
+	feeMode := common.FeeMode{HasReferral: hasReferral}
+	switch {
+	// Both token mode always charges the output token
+	case collectFeeMode == common.CollectFeeModeBothToken && tradeDirection == common.TradeDirectionAtoB:
+		feeMode.FeesOnInput, feeMode.FeesOnTokenA = false, false
+	case collectFeeMode == common.CollectFeeModeBothToken && tradeDirection == common.TradeDirectionBtoA:
+		feeMode.FeesOnInput, feeMode.FeesOnTokenA = false, true
+	// Only B mode charges token B, whichever side of the trade it is on
+	case collectFeeMode == common.CollectFeeModeOnlyB && tradeDirection == common.TradeDirectionAtoB:
+		feeMode.FeesOnInput, feeMode.FeesOnTokenA = false, false
+	case collectFeeMode == common.CollectFeeModeOnlyB && tradeDirection == common.TradeDirectionBtoA:
+		feeMode.FeesOnInput, feeMode.FeesOnTokenA = true, false
+	default:
+		return common.FeeMode{}, fmt.Errorf("invalid trade direction: %d", tradeDirection)
+	}
+
+	return feeMode, nil
+}
+
+// FeeOnAmount is the split of a trading fee charged on an amount
+type FeeOnAmount struct {
+	Amount      uint64
+	LpFee       uint64
+	ProtocolFee uint64
+	PartnerFee  uint64
+	ReferralFee uint64
+}
+
+// GetFeeOnAmount charges the trading fee on amount and splits it between LPs, protocol, partner and referrer
+func GetFeeOnAmount(poolFees common.PoolFeesStruct, amount uint64, tradeFeeNumerator uint64, hasReferral bool, hasPartner bool) (FeeOnAmount, error) {
+	tradingFee := toUint64(mulDiv(new(big.Int).SetUint64(amount), new(big.Int).SetUint64(tradeFeeNumerator), big.NewInt(common.FeeDenominator), RoundingUp))
+	if tradingFee > amount {
+		return FeeOnAmount{}, fmt.Errorf("trading fee %d exceeds amount %d", tradingFee, amount)
+	}
+
+	protocolFee := percentOf(tradingFee, poolFees.ProtocolFeePercent)
+	lpFee := tradingFee - protocolFee
+
+	var referralFee uint64
+	if hasReferral {
+		referralFee = percentOf(protocolFee, poolFees.ReferralFeePercent)
+	}
+	protocolFee -= referralFee
+
+	var partnerFee uint64
+	if hasPartner {
+		partnerFee = percentOf(protocolFee, poolFees.PartnerFeePercent)
+	}
+	protocolFee -= partnerFee
+
+	return FeeOnAmount{
+		Amount:      amount - tradingFee,
+		LpFee:       lpFee,
+		ProtocolFee: protocolFee,
+		PartnerFee:  partnerFee,
+		ReferralFee: referralFee,
+	}, nil
+}
+
+// percentOf returns percent of amount rounded down, in u128 so the product cannot overflow
+func percentOf(amount uint64, percent uint8) uint64 {
+	return toUint64(mulDiv(new(big.Int).SetUint64(amount), big.NewInt(int64(percent)), big.NewInt(100), RoundingDown))
+}
+
+// GetNextSqrtPriceFromInput returns the sqrt price after swapping amountIn into the pool
+func GetNextSqrtPriceFromInput(sqrtPrice uint128.Uint128, liquidity uint128.Uint128, amountIn uint64, aToB bool) (uint128.Uint128, error) {
+	if sqrtPrice.IsZero() || liquidity.IsZero() {
+		return uint128.Zero, fmt.Errorf("pool has no liquidity")
+	}
+	if amountIn == 0 {
+		return sqrtPrice, nil
+	}
+
+	amount := new(big.Int).SetUint64(amountIn)
+	if aToB {
+		// √P' = √P * L / (L + Δa * √P), rounded up
+		denominator := new(big.Int).Mul(amount, sqrtPrice.Big())
+		denominator.Add(denominator, liquidity.Big())
+		return toUint128(mulDiv(liquidity.Big(), sqrtPrice.Big(), denominator, RoundingUp)), nil
+	}
+
+	// √P' = √P + Δb / L, rounded down
+	quotient := shlDiv(amount, liquidity.Big(), 2*common.ScaleOffset, RoundingDown)
+	next := quotient.Add(quotient, sqrtPrice.Big())
+	if next.BitLen() > 128 {
+		return uint128.Zero, fmt.Errorf("sqrt price overflow")
+	}
+	return uint128.FromBig(next), nil
+}
+
+// getSwapAmount swaps amountIn without fees, returning the output amount and the next sqrt price
+func getSwapAmount(pool *common.Pool, amountIn uint64, aToB bool) (uint64, uint128.Uint128, error) {
+	nextSqrtPrice, err := GetNextSqrtPriceFromInput(pool.SqrtPrice, pool.Liquidity, amountIn, aToB)
+	if err != nil {
+		return 0, uint128.Zero, err
+	}
+
+	if aToB {
+		if nextSqrtPrice.Cmp(pool.SqrtMinPrice) < 0 {
+			return 0, uint128.Zero, fmt.Errorf("trade is over price range")
+		}
+		return toUint64(GetDeltaAmountB(nextSqrtPrice, pool.SqrtPrice, pool.Liquidity, RoundingDown)), nextSqrtPrice, nil
+	}
+
+	if nextSqrtPrice.Cmp(pool.SqrtMaxPrice) > 0 {
+		return 0, uint128.Zero, fmt.Errorf("trade is over price range")
+	}
+	return toUint64(GetDeltaAmountA(pool.SqrtPrice, nextSqrtPrice, pool.Liquidity, RoundingDown)), nextSqrtPrice, nil
+}
+
+// GetSwapQuote quotes an exact input swap, charging fees on the token and side selected by the pool's CollectFeeMode.
+// currentPoint is a slot or a unix timestamp depending on the pool's ActivationType.
+func GetSwapQuote(pool *common.Pool, amountIn uint64, aToB bool, hasReferral bool, currentPoint uint64) (*common.SwapQuote, error) {
+	tradeDirection := common.TradeDirectionBtoA
+	if aToB {
+		tradeDirection = common.TradeDirectionAtoB
+	}
+
+	feeMode, err := GetFeeMode(pool.CollectFeeMode, tradeDirection, hasReferral)
+	if err != nil {
+		return nil, err
+	}
+
+	tradeFeeNumerator, err := GetTotalTradingFee(pool.PoolFees, currentPoint, pool.ActivationPoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trading fee: %w", err)
+	}
+
+	hasPartner := !pool.Partner.Equals(solana.PublicKey{})
+
+	var fees FeeOnAmount
+	actualAmountIn := amountIn
+	if feeMode.FeesOnInput {
+		fees, err = GetFeeOnAmount(pool.PoolFees, amountIn, tradeFeeNumerator, hasReferral, hasPartner)
+		if err != nil {
+			return nil, err
+		}
+		actualAmountIn = fees.Amount
+	}
+
+	outputAmount, nextSqrtPrice, err := getSwapAmount(pool, actualAmountIn, aToB)
+	if err != nil {
+		return nil, err
+	}
+
+	actualAmountOut := outputAmount
+	if !feeMode.FeesOnInput {
+		fees, err = GetFeeOnAmount(pool.PoolFees, outputAmount, tradeFeeNumerator, hasReferral, hasPartner)
+		if err != nil {
+			return nil, err
+		}
+		actualAmountOut = fees.Amount
+	}
+
+	return &common.SwapQuote{
+		AmountIn:          amountIn,
+		AmountOut:         actualAmountOut,
+		NextSqrtPrice:     nextSqrtPrice,
+		TradeFeeNumerator: tradeFeeNumerator,
+		FeeMode:           feeMode,
+		LpFee:             fees.LpFee,
+		ProtocolFee:       fees.ProtocolFee,
+		PartnerFee:        fees.PartnerFee,
+		ReferralFee:       fees.ReferralFee,
+	}, nil
+}
@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+)
+
+func TestGetFeeOnAmountDoesNotOverflow(t *testing.T) {
+	poolFees := common.PoolFeesStruct{ProtocolFeePercent: 20, ReferralFeePercent: 20, PartnerFeePercent: 50}
+
+	// A 50% fee on the largest amount, whose products with the percentages overflow 64 bits
+	fee, err := GetFeeOnAmount(poolFees, math.MaxUint64, common.FeeDenominator/2, true, true)
+	if err != nil {
+		t.Fatalf("failed to compute fee: %v", err)
+	}
+
+	// Rounded up from half of an odd amount
+	const tradingFee = math.MaxUint64/2 + 1
+
+	protocolFee := uint64(tradingFee / 5)
+	referralFee := protocolFee / 5
+	partnerFee := (protocolFee - referralFee) / 2
+	want := FeeOnAmount{
+		Amount:      math.MaxUint64 - tradingFee,
+		LpFee:       tradingFee - protocolFee,
+		ProtocolFee: protocolFee - referralFee - partnerFee,
+		PartnerFee:  partnerFee,
+		ReferralFee: referralFee,
+	}
+	if fee != want {
+		t.Fatalf("expected %+v, got %+v", want, fee)
+	}
+}
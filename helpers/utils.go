@@ -1,48 +1,44 @@
 package helpers
 
 import (
+	"math/big"
+	"time"
+
 	"github.com/dannwee/dbc-go/common"
 	"lukechampine.com/uint128"
 )
 
-// GetUnclaimReward calculates the unclaimed rewards for a position
+// GetUnclaimReward calculates the unclaimed fees and rewards for a position at the current time
 func GetUnclaimReward(poolState *common.Pool, positionState *common.PositionState) (*common.UnclaimReward, error) {
-	// Calculate total position liquidity
-	totalPositionLiquidity := positionState.UnlockedLiquidity.
-		Add(positionState.VestedLiquidity).
-		Add(positionState.PermanentLockedLiquidity)
+	return GetUnclaimRewardAt(poolState, positionState, uint64(time.Now().Unix()))
+}
 
-	// Convert byte arrays to uint128
-	feeAPerLiquidity := uint128.FromBytes(poolState.FeeAPerLiquidity[:])
-	feeBPerLiquidity := uint128.FromBytes(poolState.FeeBPerLiquidity[:])
-	feeAPerTokenCheckpoint := uint128.FromBytes(positionState.FeeAPerTokenCheckpoint[:])
-	feeBPerTokenCheckpoint := uint128.FromBytes(positionState.FeeBPerTokenCheckpoint[:])
+// GetUnclaimRewardAt calculates the unclaimed fees and rewards for a position at the given unix timestamp
+func GetUnclaimRewardAt(poolState *common.Pool, positionState *common.PositionState, currentTime uint64) (*common.UnclaimReward, error) {
+	if err := poolState.CollectFeeMode.Validate(); err != nil {
+		return nil, err
+	}
 
-	// Calculate fee per token stored for token A
-	feeAPerTokenStored := feeAPerLiquidity.Sub(feeAPerTokenCheckpoint)
+	// Calculate total position liquidity
+	totalPositionLiquidity := getPositionTotalLiquidity(positionState)
 
-	// Calculate fee per token stored for token B
-	feeBPerTokenStored := feeBPerLiquidity.Sub(feeBPerTokenCheckpoint)
+	// Fees accrue on swaps, so they only depend on the fee per liquidity the pool has stored
+	feeA := getPendingFee(totalPositionLiquidity, poolState.FeeAPerLiquidity, positionState.FeeAPerTokenCheckpoint)
+	feeB := getPendingFee(totalPositionLiquidity, poolState.FeeBPerLiquidity, positionState.FeeBPerTokenCheckpoint)
 
-	// Calculate fees with safe multiplication
-	// First divide by 2^32 to prevent overflow, then multiply
-	feeA := totalPositionLiquidity.Rsh(32).Mul(feeAPerTokenStored).Rsh(common.LIQUIDITY_SCALE - 32)
-	feeB := totalPositionLiquidity.Rsh(32).Mul(feeBPerTokenStored).Rsh(common.LIQUIDITY_SCALE - 32)
+	// Calculate total fees including pending
+	totalFeeA := uint128.From64(positionState.FeeAPending).Add(feeA)
+	totalFeeB := uint128.From64(positionState.FeeBPending).Add(feeB)
 
-	// Convert pending fees to uint128
-	feeAPending := uint128.From64(positionState.FeeAPending)
-	feeBPending := uint128.From64(positionState.FeeBPending)
+	// Only B pools never charge fees in token A, so there is nothing to claim there
+	if poolState.CollectFeeMode == common.CollectFeeModeOnlyB {
+		totalFeeA = uint128.Zero
+	}
 
-	// Calculate total fees including pending
-	totalFeeA := feeAPending.Add(feeA)
-	totalFeeB := feeBPending.Add(feeB)
-
-	// Get rewards from reward infos
-	rewards := make([]uint128.Uint128, 0)
-	if len(positionState.RewardInfos) > 0 {
-		for _, info := range positionState.RewardInfos {
-			rewards = append(rewards, uint128.From64(info.RewardPendings))
-		}
+	// Rewards accrue over time, so bring each pool reward up to currentTime first
+	rewards := make([]uint128.Uint128, 0, len(positionState.RewardInfos))
+	for i := range positionState.RewardInfos {
+		rewards = append(rewards, GetPositionRewardAt(poolState, positionState, i, currentTime))
 	}
 
 	return &common.UnclaimReward{
@@ -51,3 +47,14 @@ func GetUnclaimReward(poolState *common.Pool, positionState *common.PositionStat
 		Rewards:   rewards,
 	}, nil
 }
+
+// getPendingFee computes liquidity * (feePerLiquidity - checkpoint) >> LIQUIDITY_SCALE in 256 bit precision
+func getPendingFee(liquidity uint128.Uint128, feePerLiquidity [32]uint8, checkpoint [32]uint8) uint128.Uint128 {
+	feePerTokenStored := new(big.Int).Sub(bigFromLE(feePerLiquidity[:]), bigFromLE(checkpoint[:]))
+	if feePerTokenStored.Sign() <= 0 {
+		return uint128.Zero
+	}
+
+	fee := feePerTokenStored.Mul(feePerTokenStored, liquidity.Big())
+	return toUint128(fee.Rsh(fee, common.LIQUIDITY_SCALE))
+}
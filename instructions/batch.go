@@ -0,0 +1,80 @@
+package instructions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// GetPools fetches many pools in batches. Results are in input order, with nil for pools that do not exist.
+func GetPools(ctx context.Context, poolAddresses []solana.PublicKey, rpcClient common.AccountFetcher, opts ...common.FetchOption) ([]*common.Pool, error) {
+	options := common.NewFetchOptions(opts...)
+
+	accounts, slot, err := common.GetMultipleAccountsBatchedWithSlot(ctx, rpcClient, poolAddresses, options.BatchConcurrency, options.MultipleAccountsOpts())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool accounts: %w", err)
+	}
+	options.RecordSlot(slot)
+
+	pools := make([]*common.Pool, len(accounts))
+	for i, account := range accounts {
+		if account == nil {
+			continue
+		}
+
+		pool, err := DecodePool(account, options.ProgramID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool account %s: %w", poolAddresses[i], err)
+		}
+		pools[i] = pool
+	}
+
+	return pools, nil
+}
+
+// GetVaultBalances fetches the token A and B vault balances of many pools in batches, in input order
+func GetVaultBalances(ctx context.Context, pools []*common.Pool, rpcClient common.AccountFetcher, opts ...common.FetchOption) ([]common.TokenAmounts, error) {
+	options := common.NewFetchOptions(opts...)
+
+	vaults := make([]solana.PublicKey, 0, 2*len(pools))
+	for _, pool := range pools {
+		vaults = append(vaults, pool.TokenAVault, pool.TokenBVault)
+	}
+
+	accounts, slot, err := common.GetMultipleAccountsBatchedWithSlot(ctx, rpcClient, vaults, options.BatchConcurrency, options.MultipleAccountsOpts())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault accounts: %w", err)
+	}
+	options.RecordSlot(slot)
+
+	balances := make([]common.TokenAmounts, len(pools))
+	for i := range pools {
+		amountA, err := decodeVaultAmount(vaults[2*i], accounts[2*i])
+		if err != nil {
+			return nil, err
+		}
+		amountB, err := decodeVaultAmount(vaults[2*i+1], accounts[2*i+1])
+		if err != nil {
+			return nil, err
+		}
+		balances[i] = common.TokenAmounts{AmountA: amountA, AmountB: amountB}
+	}
+
+	return balances, nil
+}
+
+func decodeVaultAmount(vault solana.PublicKey, account *rpc.Account) (uint64, error) {
+	if err := common.ValidateTokenAccount(account); err != nil {
+		return 0, fmt.Errorf("invalid vault account %s: %w", vault, err)
+	}
+
+	tokenAccount, err := common.DecodeTokenAccount(account.Data.GetBinary())
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode vault %s: %w", vault, err)
+	}
+
+	return tokenAccount.Amount, nil
+}
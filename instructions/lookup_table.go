@@ -0,0 +1,78 @@
+package instructions
+
+import (
+	"encoding/binary"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/dannwee/dbc-go/helpers"
+	"github.com/gagliardetto/solana-go"
+)
+
+// CreateLookupTable creates an empty address lookup table owned by authority.
+// recentSlot must be a recent finalized slot, it seeds the table address which is returned.
+func CreateLookupTable(
+	authority solana.PublicKey,
+	payer solana.PublicKey,
+	recentSlot uint64,
+) (solana.Instruction, solana.PublicKey, error) {
+	table, bump, err := helpers.DeriveLookupTableAddress(authority, recentSlot)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+
+	// 0 selects CreateLookupTable, followed by the recent slot and the bump
+	data := make([]byte, 4+8+1)
+	binary.LittleEndian.PutUint32(data[0:4], 0)
+	binary.LittleEndian.PutUint64(data[4:12], recentSlot)
+	data[12] = bump
+
+	acctMeta := solana.AccountMetaSlice{
+		// 1. lookup_table
+		{PublicKey: table, IsSigner: false, IsWritable: true},
+		// 2. authority (signer)
+		{PublicKey: authority, IsSigner: true, IsWritable: false},
+		// 3. payer (signer)
+		{PublicKey: payer, IsSigner: true, IsWritable: true},
+		// 4. system_program
+		{PublicKey: solana.SystemProgramID, IsSigner: false, IsWritable: false},
+	}
+
+	return solana.NewInstruction(
+		solana.MustPublicKeyFromBase58(common.AddressLookupTableProgram),
+		acctMeta,
+		data,
+	), table, nil
+}
+
+// ExtendLookupTable appends addresses to a lookup table owned by authority
+func ExtendLookupTable(
+	table solana.PublicKey,
+	authority solana.PublicKey,
+	payer solana.PublicKey,
+	addresses solana.PublicKeySlice,
+) solana.Instruction {
+	// 2 selects ExtendLookupTable, followed by the address vector
+	data := make([]byte, 4+8, 4+8+32*len(addresses))
+	binary.LittleEndian.PutUint32(data[0:4], 2)
+	binary.LittleEndian.PutUint64(data[4:12], uint64(len(addresses)))
+	for _, address := range addresses {
+		data = append(data, address.Bytes()...)
+	}
+
+	acctMeta := solana.AccountMetaSlice{
+		// 1. lookup_table
+		{PublicKey: table, IsSigner: false, IsWritable: true},
+		// 2. authority (signer)
+		{PublicKey: authority, IsSigner: true, IsWritable: false},
+		// 3. payer (signer)
+		{PublicKey: payer, IsSigner: true, IsWritable: true},
+		// 4. system_program
+		{PublicKey: solana.SystemProgramID, IsSigner: false, IsWritable: false},
+	}
+
+	return solana.NewInstruction(
+		solana.MustPublicKeyFromBase58(common.AddressLookupTableProgram),
+		acctMeta,
+		data,
+	)
+}
@@ -0,0 +1,111 @@
+package instructions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/dannwee/dbc-go/helpers"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// GetPositionOwner resolves the token account and wallet currently holding a position's NFT
+func GetPositionOwner(ctx context.Context, positionAddress solana.PublicKey, rpcClient common.AccountFetcher, opts ...common.FetchOption) (*common.PositionOwner, error) {
+	position, err := GetPosition(ctx, positionAddress, rpcClient, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetPositionOwnerByNft(ctx, position.NftMint, rpcClient, opts...)
+}
+
+// GetPositionOwnerByNft resolves the token account and wallet currently holding a position NFT.
+// The NFT is minted into the position NFT account PDA, so that account is checked first. Once the
+// NFT has been transferred away, its holder is found through getTokenLargestAccounts.
+func GetPositionOwnerByNft(ctx context.Context, positionNft solana.PublicKey, rpcClient common.AccountFetcher, opts ...common.FetchOption) (*common.PositionOwner, error) {
+	options := common.NewFetchOptions(opts...)
+
+	positionAddress, err := helpers.DerivePositionPDAForProgram(positionNft, options.ProgramID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive position address: %w", err)
+	}
+	nftAccountAddress, err := helpers.DerivePositionNftAccountPDAForProgram(positionNft, options.ProgramID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive position NFT account address: %w", err)
+	}
+
+	result, err := rpcClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{positionAddress, nftAccountAddress}, options.MultipleAccountsOpts())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position accounts: %w", err)
+	}
+	if len(result.Value) != 2 {
+		return nil, fmt.Errorf("expected 2 accounts, got %d", len(result.Value))
+	}
+	options.RecordSlot(result.Context.Slot)
+
+	if err := common.ValidateAccount(result.Value[0], options.ProgramID, common.PositionAccountSize, common.PositionDiscriminator); err != nil {
+		return nil, fmt.Errorf("invalid position account %s: %w", positionAddress, err)
+	}
+
+	tokenAccount, err := decodePositionNftAccount(positionNft, result.Value[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid position NFT account %s: %w", nftAccountAddress, err)
+	}
+
+	if tokenAccount == nil {
+		nftAccountAddress, err = getPositionNftAccount(ctx, rpcClient, positionNft, options)
+		if err != nil {
+			return nil, err
+		}
+
+		account, err := rpcClient.GetAccountInfoWithOpts(ctx, nftAccountAddress, options.AccountInfoOpts())
+		if err != nil && !errors.Is(err, rpc.ErrNotFound) {
+			return nil, fmt.Errorf("failed to get position NFT account: %w", err)
+		}
+		var nftAccount *rpc.Account
+		if account != nil {
+			nftAccount = account.Value
+			options.RecordSlot(account.Context.Slot)
+		}
+
+		tokenAccount, err = decodePositionNftAccount(positionNft, nftAccount)
+		if err == nil && tokenAccount == nil {
+			err = fmt.Errorf("does not hold the position NFT")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid position NFT account %s: %w", nftAccountAddress, err)
+		}
+	}
+
+	return &common.PositionOwner{
+		Position:           positionAddress,
+		PositionNft:        positionNft,
+		PositionNftAccount: nftAccountAddress,
+		Owner:              tokenAccount.Owner,
+		Frozen:             tokenAccount.State == common.TokenAccountStateFrozen,
+		Delegate:           tokenAccount.Delegate,
+		Delegated:          !tokenAccount.Delegate.IsZero() && tokenAccount.DelegatedAmount > 0,
+	}, nil
+}
+
+// decodePositionNftAccount decodes a token account, returning nil if it is missing or does not hold the NFT
+func decodePositionNftAccount(positionNft solana.PublicKey, account *rpc.Account) (*common.TokenAccountLayout, error) {
+	if account == nil {
+		return nil, nil
+	}
+	if err := common.ValidateTokenAccount(account); err != nil {
+		return nil, err
+	}
+
+	tokenAccount, err := common.DecodeTokenAccount(account.Data.GetBinary())
+	if err != nil {
+		return nil, err
+	}
+	if !tokenAccount.Mint.Equals(positionNft) || tokenAccount.Amount != 1 {
+		return nil, nil
+	}
+
+	return tokenAccount, nil
+}
@@ -0,0 +1,101 @@
+package instructions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/dannwee/dbc-go/helpers"
+	"github.com/gagliardetto/solana-go"
+)
+
+// GetPoolInfo returns a pool together with its vault balances, token mints and reward mints.
+// It makes two round trips: the pool is read first to learn the related addresses, then every account,
+// the pool included, is read in one getMultipleAccounts request so all values come from the same slot.
+// The first read of the pool is discarded. Use GetPoolInfoFromPool to save it when the pool is already known.
+func GetPoolInfo(ctx context.Context, poolAddress solana.PublicKey, rpcClient common.AccountFetcher, opts ...common.FetchOption) (*common.PoolInfo, error) {
+	// Only the snapshot in GetPoolInfoFromPool counts towards the context slot
+	pool, _, err := GetPoolWithSlot(ctx, poolAddress, rpcClient, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetPoolInfoFromPool(ctx, poolAddress, pool, rpcClient, opts...)
+}
+
+// GetPoolInfoFromPool is GetPoolInfo in a single getMultipleAccounts round trip, taking the related
+// addresses from an already decoded pool. The returned pool state is read again in the same request.
+// Reward mints initialized after pool was read are left out.
+func GetPoolInfoFromPool(ctx context.Context, poolAddress solana.PublicKey, pool *common.Pool, rpcClient common.AccountFetcher, opts ...common.FetchOption) (*common.PoolInfo, error) {
+	options := common.NewFetchOptions(opts...)
+
+	addresses := []solana.PublicKey{
+		poolAddress,
+		pool.TokenAVault,
+		pool.TokenBVault,
+		pool.TokenAMint,
+		pool.TokenBMint,
+	}
+	for _, rewardInfo := range pool.RewardInfos {
+		if rewardInfo.Initialized != 0 {
+			addresses = append(addresses, rewardInfo.Mint)
+		}
+	}
+
+	result, err := rpcClient.GetMultipleAccountsWithOpts(ctx, addresses, options.MultipleAccountsOpts())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool accounts: %w", err)
+	}
+	if len(result.Value) != len(addresses) {
+		return nil, fmt.Errorf("expected %d accounts, got %d", len(addresses), len(result.Value))
+	}
+	options.RecordSlot(result.Context.Slot)
+
+	// Decode the pool again so it matches the other accounts
+	pool, err = DecodePool(result.Value[0], options.ProgramID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool account %s: %w", poolAddress, err)
+	}
+	for i := 1; i < 3; i++ {
+		if err := common.ValidateTokenAccount(result.Value[i]); err != nil {
+			return nil, fmt.Errorf("invalid vault account %s: %w", addresses[i], err)
+		}
+	}
+	for i := 3; i < len(addresses); i++ {
+		if err := common.ValidateMint(result.Value[i]); err != nil {
+			return nil, fmt.Errorf("invalid mint account %s: %w", addresses[i], err)
+		}
+	}
+
+	tokenAVault, err := common.DecodeTokenAccount(result.Value[1].Data.GetBinary())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token A vault: %w", err)
+	}
+	tokenBVault, err := common.DecodeTokenAccount(result.Value[2].Data.GetBinary())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token B vault: %w", err)
+	}
+
+	mints := make([]common.MintInfo, 0, len(addresses)-3)
+	for i := 3; i < len(addresses); i++ {
+		mintInfo, err := common.DecodeMint(addresses[i], result.Value[i].Owner, result.Value[i].Data.GetBinary())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode mint %s: %w", addresses[i], err)
+		}
+		mints = append(mints, *mintInfo)
+	}
+
+	return &common.PoolInfo{
+		Pool:      poolAddress,
+		PoolState: *pool,
+		Slot:      result.Context.Slot,
+		VaultBalances: common.TokenAmounts{
+			AmountA: tokenAVault.Amount,
+			AmountB: tokenBVault.Amount,
+		},
+		TokenAMint:  mints[0],
+		TokenBMint:  mints[1],
+		RewardMints: mints[2:],
+		Price:       helpers.GetPoolPrice(pool, mints[0].Decimals, mints[1].Decimals),
+	}, nil
+}
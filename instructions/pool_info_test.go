@@ -0,0 +1,50 @@
+package instructions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"lukechampine.com/uint128"
+)
+
+// poolInfoFetcher serves a zeroed pool whose vaults and mints all point at one token account,
+// which is large enough to pass as both a vault and a mint
+func poolInfoFetcher(poolAddress solana.PublicKey) *fakeAccountFetcher {
+	return &fakeAccountFetcher{accounts: map[solana.PublicKey]*rpc.Account{
+		poolAddress:        programAccount(testProgramID, common.PoolAccountSize, common.PoolDiscriminator),
+		solana.PublicKey{}: programAccount(solana.TokenProgramID, common.TokenAccountSize, nil),
+	}}
+}
+
+func TestGetPoolInfoRoundTrips(t *testing.T) {
+	poolAddress := solana.NewWallet().PublicKey()
+
+	fetcher := poolInfoFetcher(poolAddress)
+	info, err := GetPoolInfo(context.Background(), poolAddress, fetcher)
+	if err != nil {
+		t.Fatalf("failed to get pool info: %v", err)
+	}
+	if fetcher.accountInfoCalls != 1 || fetcher.multipleAccountsCalls != 1 {
+		t.Fatalf("expected a pool read and one snapshot, got %d and %d", fetcher.accountInfoCalls, fetcher.multipleAccountsCalls)
+	}
+	if info.Slot != 42 {
+		t.Fatalf("expected the snapshot slot, got %d", info.Slot)
+	}
+
+	// A caller holding the pool skips the first read, and gets the pool as of the snapshot back
+	fetcher = poolInfoFetcher(poolAddress)
+	stale := &common.Pool{SqrtPrice: uint128.From64(7)}
+	info, err = GetPoolInfoFromPool(context.Background(), poolAddress, stale, fetcher)
+	if err != nil {
+		t.Fatalf("failed to get pool info: %v", err)
+	}
+	if fetcher.accountInfoCalls != 0 || fetcher.multipleAccountsCalls != 1 {
+		t.Fatalf("expected a single snapshot, got %d and %d", fetcher.accountInfoCalls, fetcher.multipleAccountsCalls)
+	}
+	if !info.PoolState.SqrtPrice.IsZero() {
+		t.Fatalf("expected the pool state from the snapshot, got sqrt price %s", info.PoolState.SqrtPrice)
+	}
+}
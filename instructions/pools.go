@@ -0,0 +1,180 @@
+package instructions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/dannwee/dbc-go/helpers"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Offsets of pool fields in a pool account
+const (
+	poolTokenAMintOffset = 168
+	poolTokenBMintOffset = 200
+	poolPartnerOffset    = 328
+)
+
+// getPoolsWithFilters runs getProgramAccounts for pool accounts matching all memcmp filters
+func getPoolsWithFilters(ctx context.Context, rpcClient common.AccountFetcher, options common.FetchOptions, memcmps ...*rpc.RPCFilterMemcmp) ([]common.PoolResult, error) {
+	filters := []rpc.RPCFilter{
+		{DataSize: common.PoolAccountSize},
+		{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: common.PoolDiscriminator}},
+	}
+	for _, memcmp := range memcmps {
+		filters = append(filters, rpc.RPCFilter{Memcmp: memcmp})
+	}
+
+	accounts, err := rpcClient.GetProgramAccountsWithOpts(ctx, options.ProgramID, options.ProgramAccountsOpts(filters, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool accounts: %w", err)
+	}
+
+	pools := make([]common.PoolResult, 0, len(accounts))
+	for _, account := range accounts {
+		pool, err := DecodePool(account.Account, options.ProgramID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool account %s: %w", account.Pubkey, err)
+		}
+		pools = append(pools, common.PoolResult{Pool: account.Pubkey, PoolState: *pool})
+	}
+
+	return pools, nil
+}
+
+// GetAllPools lists every pool of the program in a single getProgramAccounts request
+func GetAllPools(ctx context.Context, rpcClient common.AccountFetcher, opts ...common.FetchOption) ([]common.PoolResult, error) {
+	return getPoolsWithFilters(ctx, rpcClient, common.NewFetchOptions(opts...))
+}
+
+// GetPoolsByMint finds all pools with the mint on either side
+func GetPoolsByMint(ctx context.Context, mint solana.PublicKey, rpcClient common.AccountFetcher, sortBy common.PoolSortBy, opts ...common.FetchOption) ([]common.PoolResult, error) {
+	options := common.NewFetchOptions(opts...)
+
+	poolsA, err := getPoolsWithFilters(ctx, rpcClient, options, &rpc.RPCFilterMemcmp{Offset: poolTokenAMintOffset, Bytes: mint.Bytes()})
+	if err != nil {
+		return nil, err
+	}
+
+	poolsB, err := getPoolsWithFilters(ctx, rpcClient, options, &rpc.RPCFilterMemcmp{Offset: poolTokenBMintOffset, Bytes: mint.Bytes()})
+	if err != nil {
+		return nil, err
+	}
+
+	return SortPools(mergePools(poolsA, poolsB), sortBy), nil
+}
+
+// GetPoolsByPair finds all pools for a mint pair, in either token order
+func GetPoolsByPair(ctx context.Context, mintX solana.PublicKey, mintY solana.PublicKey, rpcClient common.AccountFetcher, sortBy common.PoolSortBy, opts ...common.FetchOption) ([]common.PoolResult, error) {
+	options := common.NewFetchOptions(opts...)
+
+	poolsXY, err := getPoolsWithFilters(ctx, rpcClient, options,
+		&rpc.RPCFilterMemcmp{Offset: poolTokenAMintOffset, Bytes: mintX.Bytes()},
+		&rpc.RPCFilterMemcmp{Offset: poolTokenBMintOffset, Bytes: mintY.Bytes()},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	poolsYX, err := getPoolsWithFilters(ctx, rpcClient, options,
+		&rpc.RPCFilterMemcmp{Offset: poolTokenAMintOffset, Bytes: mintY.Bytes()},
+		&rpc.RPCFilterMemcmp{Offset: poolTokenBMintOffset, Bytes: mintX.Bytes()},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return SortPools(mergePools(poolsXY, poolsYX), sortBy), nil
+}
+
+// GetPoolsByPartner finds all pools of a partner
+func GetPoolsByPartner(ctx context.Context, partner solana.PublicKey, rpcClient common.AccountFetcher, sortBy common.PoolSortBy, opts ...common.FetchOption) ([]common.PoolResult, error) {
+	options := common.NewFetchOptions(opts...)
+
+	pools, err := getPoolsWithFilters(ctx, rpcClient, options, &rpc.RPCFilterMemcmp{Offset: poolPartnerOffset, Bytes: partner.Bytes()})
+	if err != nil {
+		return nil, err
+	}
+
+	return SortPools(pools, sortBy), nil
+}
+
+// GetPoolsByConfig finds all pools created from a config. Pools do not store their config, so every
+// pool's mints are listed and kept only when the pool PDA derived from the config matches its address.
+func GetPoolsByConfig(ctx context.Context, config solana.PublicKey, rpcClient common.AccountFetcher, sortBy common.PoolSortBy, opts ...common.FetchOption) ([]common.PoolResult, error) {
+	options := common.NewFetchOptions(opts...)
+
+	filters := []rpc.RPCFilter{
+		{DataSize: common.PoolAccountSize},
+		{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: common.PoolDiscriminator}},
+	}
+	dataSlice := &rpc.DataSlice{Offset: uint64Ptr(poolTokenAMintOffset), Length: uint64Ptr(64)}
+
+	accounts, err := rpcClient.GetProgramAccountsWithOpts(ctx, options.ProgramID, options.ProgramAccountsOpts(filters, dataSlice))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool accounts: %w", err)
+	}
+
+	var poolAddresses []solana.PublicKey
+	for _, account := range accounts {
+		data := account.Account.Data.GetBinary()
+		if len(data) < 64 {
+			continue
+		}
+
+		poolAddress, err := helpers.DerivePoolPDAForProgram(config, solana.PublicKeyFromBytes(data[0:32]), solana.PublicKeyFromBytes(data[32:64]), options.ProgramID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive pool address: %w", err)
+		}
+		if poolAddress.Equals(account.Pubkey) {
+			poolAddresses = append(poolAddresses, account.Pubkey)
+		}
+	}
+
+	poolStates, err := GetPools(ctx, poolAddresses, rpcClient, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	pools := make([]common.PoolResult, 0, len(poolStates))
+	for i, poolState := range poolStates {
+		if poolState == nil {
+			continue
+		}
+		pools = append(pools, common.PoolResult{Pool: poolAddresses[i], PoolState: *poolState})
+	}
+
+	return SortPools(pools, sortBy), nil
+}
+
+// SortPools sorts pools in place by liquidity or fee tier and returns them
+func SortPools(pools []common.PoolResult, sortBy common.PoolSortBy) []common.PoolResult {
+	sort.SliceStable(pools, func(i, j int) bool {
+		switch sortBy {
+		case common.PoolSortByFee:
+			return pools[i].PoolState.PoolFees.BaseFee.CliffFeeNumerator < pools[j].PoolState.PoolFees.BaseFee.CliffFeeNumerator
+		default:
+			return pools[i].PoolState.Liquidity.Cmp(pools[j].PoolState.Liquidity) > 0
+		}
+	})
+	return pools
+}
+
+// mergePools concatenates pool lists, dropping duplicates
+func mergePools(lists ...[]common.PoolResult) []common.PoolResult {
+	seen := make(map[solana.PublicKey]bool)
+	var merged []common.PoolResult
+	for _, list := range lists {
+		for _, pool := range list {
+			if seen[pool.Pool] {
+				continue
+			}
+			seen[pool.Pool] = true
+			merged = append(merged, pool)
+		}
+	}
+	return merged
+}
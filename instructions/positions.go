@@ -0,0 +1,267 @@
+package instructions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/dannwee/dbc-go/helpers"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Offset of PositionState.Pool in a position account
+const positionPoolOffset = 8
+
+type GetAllPositionsByPoolOpts struct {
+	// Resolve the token account and wallet currently holding each position NFT
+	ResolveOwners bool
+	// Page through the position addresses, which are sorted so pages are stable
+	Offset int
+	// Maximum number of positions to load, 0 loads all of them
+	Limit int
+}
+
+// GetPositionAddressesByPool lists the addresses of all positions in a pool, sorted.
+// Account data is sliced away so the response only carries addresses.
+func GetPositionAddressesByPool(ctx context.Context, pool solana.PublicKey, rpcClient common.AccountFetcher, opts ...common.FetchOption) ([]solana.PublicKey, error) {
+	options := common.NewFetchOptions(opts...)
+
+	filters := []rpc.RPCFilter{
+		{DataSize: common.PositionAccountSize},
+		{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: common.PositionDiscriminator}},
+		{Memcmp: &rpc.RPCFilterMemcmp{Offset: positionPoolOffset, Bytes: pool.Bytes()}},
+	}
+	dataSlice := &rpc.DataSlice{Offset: uint64Ptr(0), Length: uint64Ptr(0)}
+
+	accounts, err := rpcClient.GetProgramAccountsWithOpts(ctx, options.ProgramID, options.ProgramAccountsOpts(filters, dataSlice))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position accounts: %w", err)
+	}
+
+	addresses := make([]solana.PublicKey, 0, len(accounts))
+	for _, account := range accounts {
+		addresses = append(addresses, account.Pubkey)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+
+	return addresses, nil
+}
+
+// GetPositionsByPool loads every position of a pool with a single getProgramAccounts request.
+// Holders are not resolved, use GetAllPositionsByPool for that or for paging.
+func GetPositionsByPool(ctx context.Context, pool solana.PublicKey, rpcClient common.AccountFetcher, opts ...common.FetchOption) ([]common.PositionResult, error) {
+	return getPositionsWithFilters(ctx, rpcClient, common.NewFetchOptions(opts...), &rpc.RPCFilterMemcmp{Offset: positionPoolOffset, Bytes: pool.Bytes()})
+}
+
+// GetAllPositions loads every position of the program with a single getProgramAccounts request.
+// Holders are not resolved.
+func GetAllPositions(ctx context.Context, rpcClient common.AccountFetcher, opts ...common.FetchOption) ([]common.PositionResult, error) {
+	return getPositionsWithFilters(ctx, rpcClient, common.NewFetchOptions(opts...))
+}
+
+// getPositionsWithFilters loads the positions matching memcmps in one getProgramAccounts request
+func getPositionsWithFilters(ctx context.Context, rpcClient common.AccountFetcher, options common.FetchOptions, memcmps ...*rpc.RPCFilterMemcmp) ([]common.PositionResult, error) {
+	filters := []rpc.RPCFilter{
+		{DataSize: common.PositionAccountSize},
+		{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: common.PositionDiscriminator}},
+	}
+	for _, memcmp := range memcmps {
+		filters = append(filters, rpc.RPCFilter{Memcmp: memcmp})
+	}
+
+	accounts, err := rpcClient.GetProgramAccountsWithOpts(ctx, options.ProgramID, options.ProgramAccountsOpts(filters, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position accounts: %w", err)
+	}
+
+	positions := make([]common.PositionResult, 0, len(accounts))
+	for _, account := range accounts {
+		positionState, err := DecodePosition(account.Account, options.ProgramID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid position account %s: %w", account.Pubkey, err)
+		}
+		positions = append(positions, common.PositionResult{Position: account.Pubkey, PositionState: *positionState})
+	}
+
+	return positions, nil
+}
+
+// GetAllPositionsByPool loads the positions of a pool, optionally a page at a time and with their current holders
+func GetAllPositionsByPool(
+	ctx context.Context,
+	pool solana.PublicKey,
+	rpcClient common.AccountFetcher,
+	pageOpts *GetAllPositionsByPoolOpts,
+	opts ...common.FetchOption,
+) ([]common.PositionResult, error) {
+	if pageOpts == nil {
+		pageOpts = &GetAllPositionsByPoolOpts{}
+	}
+	options := common.NewFetchOptions(opts...)
+
+	addresses, err := GetPositionAddressesByPool(ctx, pool, rpcClient, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Select the requested page
+	if pageOpts.Offset >= len(addresses) {
+		return []common.PositionResult{}, nil
+	}
+	addresses = addresses[pageOpts.Offset:]
+	if pageOpts.Limit > 0 && pageOpts.Limit < len(addresses) {
+		addresses = addresses[:pageOpts.Limit]
+	}
+
+	accounts, slot, err := common.GetMultipleAccountsBatchedWithSlot(ctx, rpcClient, addresses, options.BatchConcurrency, options.MultipleAccountsOpts())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position accounts: %w", err)
+	}
+	options.RecordSlot(slot)
+
+	positionResults := make([]common.PositionResult, 0, len(accounts))
+	for i, account := range accounts {
+		// Closed between listing and loading
+		if account == nil {
+			continue
+		}
+
+		positionState, err := DecodePosition(account, options.ProgramID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid position account %s: %w", addresses[i], err)
+		}
+
+		positionResults = append(positionResults, common.PositionResult{
+			Position:      addresses[i],
+			PositionState: *positionState,
+		})
+	}
+
+	if pageOpts.ResolveOwners {
+		if err := resolvePositionHolders(ctx, rpcClient, positionResults, options); err != nil {
+			return nil, err
+		}
+	}
+
+	return positionResults, nil
+}
+
+// resolvePositionHolders fills in the token account holding each position NFT and its owner.
+// The position NFT account PDAs are read in batches, getTokenLargestAccounts is only used to find
+// NFTs that have been transferred out of their PDA.
+func resolvePositionHolders(ctx context.Context, rpcClient common.AccountFetcher, positions []common.PositionResult, options common.FetchOptions) error {
+	nftAccounts := make([]solana.PublicKey, len(positions))
+	all := make([]int, len(positions))
+	for i := range positions {
+		nftAccount, err := helpers.DerivePositionNftAccountPDAForProgram(positions[i].PositionState.NftMint, options.ProgramID)
+		if err != nil {
+			return fmt.Errorf("failed to derive position NFT account address: %w", err)
+		}
+		nftAccounts[i] = nftAccount
+		all[i] = i
+	}
+
+	moved, err := fillPositionHolders(ctx, rpcClient, positions, all, nftAccounts, options)
+	if err != nil {
+		return err
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	semaphore := make(chan struct{}, max(options.BatchConcurrency, 1))
+
+	for _, i := range moved {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			nftAccount, err := getPositionNftAccount(ctx, rpcClient, positions[i].PositionState.NftMint, options)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			nftAccounts[i] = nftAccount
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	// NFTs moved again since getTokenLargestAccounts are left without a holder
+	_, err = fillPositionHolders(ctx, rpcClient, positions, moved, nftAccounts, options)
+	return err
+}
+
+// fillPositionHolders reads the candidate NFT account of the positions at indices and fills in those holding
+// the NFT. It returns the indices of the positions whose candidate does not hold it.
+func fillPositionHolders(ctx context.Context, rpcClient common.AccountFetcher, positions []common.PositionResult, indices []int, nftAccounts []solana.PublicKey, options common.FetchOptions) ([]int, error) {
+	addresses := make([]solana.PublicKey, len(indices))
+	for j, i := range indices {
+		addresses[j] = nftAccounts[i]
+	}
+
+	accounts, slot, err := common.GetMultipleAccountsBatchedWithSlot(ctx, rpcClient, addresses, options.BatchConcurrency, options.MultipleAccountsOpts())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position NFT accounts: %w", err)
+	}
+	options.RecordSlot(slot)
+
+	var missing []int
+	for j, account := range accounts {
+		i := indices[j]
+		tokenAccount, err := decodePositionNftAccount(positions[i].PositionState.NftMint, account)
+		if err != nil {
+			return nil, fmt.Errorf("invalid position NFT account %s: %w", nftAccounts[i], err)
+		}
+		if tokenAccount == nil {
+			missing = append(missing, i)
+			continue
+		}
+
+		positions[i].PositionNftAccount = nftAccounts[i]
+		positions[i].Owner = tokenAccount.Owner
+	}
+
+	return missing, nil
+}
+
+// getPositionNftAccount finds the token account holding the single token of a position NFT mint
+func getPositionNftAccount(ctx context.Context, rpcClient common.AccountFetcher, nftMint solana.PublicKey, options common.FetchOptions) (solana.PublicKey, error) {
+	largestAccounts, err := rpcClient.GetTokenLargestAccounts(ctx, nftMint, options.Commitment)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to get largest accounts of %s: %w", nftMint, err)
+	}
+	options.RecordSlot(largestAccounts.Context.Slot)
+
+	for _, account := range largestAccounts.Value {
+		if account.Amount == "1" {
+			return account.Address, nil
+		}
+	}
+
+	return solana.PublicKey{}, fmt.Errorf("no token account holds position NFT %s", nftMint)
+}
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
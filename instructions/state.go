@@ -1,8 +1,8 @@
 package instructions
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 
@@ -12,37 +12,57 @@ import (
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
-func GetPool(ctx context.Context, poolAddress solana.PublicKey, rpcClient *rpc.Client) (*common.Pool, error) {
-	account, err := rpcClient.GetAccountInfo(ctx, poolAddress)
+func GetPool(ctx context.Context, poolAddress solana.PublicKey, rpcClient common.AccountFetcher, opts ...common.FetchOption) (*common.Pool, error) {
+	pool, slot, err := GetPoolWithSlot(ctx, poolAddress, rpcClient, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get pool account: %w", err)
+		return nil, err
 	}
+	common.NewFetchOptions(opts...).RecordSlot(slot)
+	return pool, nil
+}
 
-	if account == nil || account.Value == nil {
-		return nil, fmt.Errorf("pool account not found")
+// GetPoolWithSlot is GetPool that also returns the context slot the pool was read at
+func GetPoolWithSlot(ctx context.Context, poolAddress solana.PublicKey, rpcClient common.AccountFetcher, opts ...common.FetchOption) (*common.Pool, uint64, error) {
+	options := common.NewFetchOptions(opts...)
+
+	account, err := rpcClient.GetAccountInfoWithOpts(ctx, poolAddress, options.AccountInfoOpts())
+	if errors.Is(err, rpc.ErrNotFound) {
+		return nil, 0, fmt.Errorf("pool account %s: %w", poolAddress, common.ErrNotFound)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get pool account: %w", err)
 	}
 
-	data := account.Value.Data.GetBinary()
+	if account == nil || account.Value == nil {
+		return nil, 0, fmt.Errorf("pool account %s: %w", poolAddress, common.ErrNotFound)
+	}
 
-	if len(data) < 8 {
-		return nil, fmt.Errorf("data too short")
+	pool, err := DecodePool(account.Value, options.ProgramID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid pool account %s: %w", poolAddress, err)
 	}
+	return pool, account.Context.Slot, nil
+}
 
-	expectedDiscriminator := []byte{241, 154, 109, 4, 17, 177, 109, 188}
-	if !bytes.Equal(data[:8], expectedDiscriminator) {
-		return nil, fmt.Errorf("invalid discriminator, not a pool account")
+// DecodePool validates the owner, size and discriminator of a pool account and deserializes it
+func DecodePool(account *rpc.Account, programID solana.PublicKey) (*common.Pool, error) {
+	if err := common.ValidateAccount(account, programID, common.PoolAccountSize, common.PoolDiscriminator); err != nil {
+		return nil, err
 	}
 
-	return helpers.DeserializePool(data)
+	return helpers.DeserializePool(account.Data.GetBinary())
 }
 
 func GetPositionsByUser(
 	ctx context.Context,
-	rpcClient *rpc.Client,
+	rpcClient common.AccountFetcher,
 	user solana.PublicKey,
+	opts ...common.FetchOption,
 ) ([]common.PositionResult, error) {
+	options := common.NewFetchOptions(opts...)
+
 	// Get all position NFT accounts owned by the user
-	userPositionAccounts, err := common.GetAllPositionNftAccountByOwner(ctx, rpcClient, user)
+	userPositionAccounts, err := common.GetAllPositionNftAccountByOwner(ctx, rpcClient, user, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get position NFT accounts: %w", err)
 	}
@@ -54,27 +74,29 @@ func GetPositionsByUser(
 	// Get position addresses for each NFT
 	positionAddresses := make([]solana.PublicKey, len(userPositionAccounts))
 	for i, account := range userPositionAccounts {
-		positionAddress, err := helpers.DerivePositionPDA(account.PositionNft)
+		positionAddress, err := helpers.DerivePositionPDAForProgram(account.PositionNft, options.ProgramID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to derive position address: %w", err)
 		}
 		positionAddresses[i] = positionAddress
 	}
 
-	// Fetch all position states
+	// Fetch all position states in batches
+	positionAccounts, slot, err := common.GetMultipleAccountsBatchedWithSlot(ctx, rpcClient, positionAddresses, options.BatchConcurrency, options.MultipleAccountsOpts())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position accounts: %w", err)
+	}
+	options.RecordSlot(slot)
+
 	positionStates := make([]*common.PositionState, len(positionAddresses))
-	for i, address := range positionAddresses {
-		account, err := rpcClient.GetAccountInfo(ctx, address)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get position account: %w", err)
-		}
-		if account == nil || account.Value == nil {
+	for i, account := range positionAccounts {
+		if account == nil {
 			continue
 		}
 
-		positionState, err := helpers.DeserializePosition(account.Value.Data.GetBinary())
+		positionState, err := DecodePosition(account, options.ProgramID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to deserialize position state: %w", err)
+			return nil, fmt.Errorf("invalid position account %s: %w", positionAddresses[i], err)
 		}
 		positionStates[i] = positionState
 	}
@@ -90,6 +112,7 @@ func GetPositionsByUser(
 			PositionNftAccount: account.PositionNftAccount,
 			Position:           positionAddresses[i],
 			PositionState:      *positionStates[i],
+			Owner:              user,
 		})
 	}
 
@@ -109,38 +132,97 @@ func GetPositionsByUser(
 	return positionResults, nil
 }
 
-func GetPosition(ctx context.Context, positionAddress solana.PublicKey, rpcClient *rpc.Client) (*common.PositionState, error) {
-	account, err := rpcClient.GetAccountInfo(ctx, positionAddress)
+func GetPosition(ctx context.Context, positionAddress solana.PublicKey, rpcClient common.AccountFetcher, opts ...common.FetchOption) (*common.PositionState, error) {
+	position, slot, err := GetPositionWithSlot(ctx, positionAddress, rpcClient, opts...)
+	if err != nil {
+		return nil, err
+	}
+	common.NewFetchOptions(opts...).RecordSlot(slot)
+	return position, nil
+}
+
+// GetPositionWithSlot is GetPosition that also returns the context slot the position was read at
+func GetPositionWithSlot(ctx context.Context, positionAddress solana.PublicKey, rpcClient common.AccountFetcher, opts ...common.FetchOption) (*common.PositionState, uint64, error) {
+	options := common.NewFetchOptions(opts...)
+
+	account, err := rpcClient.GetAccountInfoWithOpts(ctx, positionAddress, options.AccountInfoOpts())
+	if errors.Is(err, rpc.ErrNotFound) {
+		return nil, 0, fmt.Errorf("position account %s: %w", positionAddress, common.ErrNotFound)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get position account: %w", err)
+		return nil, 0, fmt.Errorf("failed to get position account: %w", err)
 	}
 
 	if account == nil || account.Value == nil {
-		return nil, fmt.Errorf("position account not found")
+		return nil, 0, fmt.Errorf("position account %s: %w", positionAddress, common.ErrNotFound)
 	}
 
-	data := account.Value.Data.GetBinary()
+	position, err := DecodePosition(account.Value, options.ProgramID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid position account %s: %w", positionAddress, err)
+	}
+	return position, account.Context.Slot, nil
+}
 
-	if len(data) < 8 {
-		return nil, fmt.Errorf("data too short")
+// DecodePosition validates the owner, size and discriminator of a position account and deserializes it
+func DecodePosition(account *rpc.Account, programID solana.PublicKey) (*common.PositionState, error) {
+	if err := common.ValidateAccount(account, programID, common.PositionAccountSize, common.PositionDiscriminator); err != nil {
+		return nil, err
 	}
 
-	expectedDiscriminator := []byte{170, 188, 143, 228, 122, 64, 247, 208}
-	if !bytes.Equal(data[:8], expectedDiscriminator) {
-		return nil, fmt.Errorf("invalid discriminator, not a position account")
+	return helpers.DeserializePosition(account.Data.GetBinary())
+}
+
+func GetConfig(ctx context.Context, configAddress solana.PublicKey, rpcClient common.AccountFetcher, opts ...common.FetchOption) (*common.Config, error) {
+	config, slot, err := GetConfigWithSlot(ctx, configAddress, rpcClient, opts...)
+	if err != nil {
+		return nil, err
+	}
+	common.NewFetchOptions(opts...).RecordSlot(slot)
+	return config, nil
+}
+
+// GetConfigWithSlot is GetConfig that also returns the context slot the config was read at
+func GetConfigWithSlot(ctx context.Context, configAddress solana.PublicKey, rpcClient common.AccountFetcher, opts ...common.FetchOption) (*common.Config, uint64, error) {
+	options := common.NewFetchOptions(opts...)
+
+	account, err := rpcClient.GetAccountInfoWithOpts(ctx, configAddress, options.AccountInfoOpts())
+	if errors.Is(err, rpc.ErrNotFound) {
+		return nil, 0, fmt.Errorf("config account %s: %w", configAddress, common.ErrNotFound)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get config account: %w", err)
+	}
+
+	if account == nil || account.Value == nil {
+		return nil, 0, fmt.Errorf("config account %s: %w", configAddress, common.ErrNotFound)
+	}
+
+	config, err := DecodeConfig(account.Value, options.ProgramID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid config account %s: %w", configAddress, err)
+	}
+	return config, account.Context.Slot, nil
+}
+
+// DecodeConfig validates the owner, size and discriminator of a config account and deserializes it
+func DecodeConfig(account *rpc.Account, programID solana.PublicKey) (*common.Config, error) {
+	if err := common.ValidateAccount(account, programID, common.ConfigAccountSize, common.ConfigDiscriminator); err != nil {
+		return nil, err
 	}
 
-	return helpers.DeserializePosition(data)
+	return helpers.DeserializeConfig(account.Data.GetBinary())
 }
 
 func GetUserPositionByPool(
 	ctx context.Context,
-	rpcClient *rpc.Client,
+	rpcClient common.AccountFetcher,
 	pool solana.PublicKey,
 	user solana.PublicKey,
+	opts ...common.FetchOption,
 ) ([]common.PositionResult, error) {
 	// Get all positions for the user
-	allPositions, err := GetPositionsByUser(ctx, rpcClient, user)
+	allPositions, err := GetPositionsByUser(ctx, rpcClient, user, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user positions: %w", err)
 	}
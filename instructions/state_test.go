@@ -0,0 +1,219 @@
+package instructions
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+var testProgramID = solana.MustPublicKeyFromBase58(common.DammV2ProgramID)
+
+// fakeAccountFetcher serves getAccountInfo and getMultipleAccounts from accounts, returning err if set
+type fakeAccountFetcher struct {
+	common.AccountFetcher
+	accounts map[solana.PublicKey]*rpc.Account
+	err      error
+	// Request counts
+	accountInfoCalls      int
+	multipleAccountsCalls int
+}
+
+func (f *fakeAccountFetcher) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey, opts *rpc.GetAccountInfoOpts) (*rpc.GetAccountInfoResult, error) {
+	f.accountInfoCalls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &rpc.GetAccountInfoResult{Value: f.accounts[account]}, nil
+}
+
+func (f *fakeAccountFetcher) GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey, opts *rpc.GetMultipleAccountsOpts) (*rpc.GetMultipleAccountsResult, error) {
+	f.multipleAccountsCalls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	result := &rpc.GetMultipleAccountsResult{
+		RPCContext: rpc.RPCContext{Context: rpc.Context{Slot: 42}},
+		Value:      make([]*rpc.Account, len(accounts)),
+	}
+	for i, account := range accounts {
+		result.Value[i] = f.accounts[account]
+	}
+	return result, nil
+}
+
+// programAccount returns a zeroed account of size bytes owned by owner and starting with discriminator
+func programAccount(owner solana.PublicKey, size int, discriminator []byte) *rpc.Account {
+	data := make([]byte, size)
+	copy(data, discriminator)
+	return &rpc.Account{Owner: owner, Data: rpc.DataBytesOrJSONFromBytes(data)}
+}
+
+// accountKind is a cp_amm account type with the decoder and fetcher that validate it
+type accountKind struct {
+	name          string
+	size          int
+	discriminator []byte
+	decode        func(account *rpc.Account) error
+	get           func(fetcher common.AccountFetcher, address solana.PublicKey) error
+}
+
+var accountKinds = []accountKind{
+	{
+		name:          "pool",
+		size:          common.PoolAccountSize,
+		discriminator: common.PoolDiscriminator,
+		decode: func(account *rpc.Account) error {
+			_, err := DecodePool(account, testProgramID)
+			return err
+		},
+		get: func(fetcher common.AccountFetcher, address solana.PublicKey) error {
+			_, _, err := GetPoolWithSlot(context.Background(), address, fetcher)
+			return err
+		},
+	},
+	{
+		name:          "position",
+		size:          common.PositionAccountSize,
+		discriminator: common.PositionDiscriminator,
+		decode: func(account *rpc.Account) error {
+			_, err := DecodePosition(account, testProgramID)
+			return err
+		},
+		get: func(fetcher common.AccountFetcher, address solana.PublicKey) error {
+			_, _, err := GetPositionWithSlot(context.Background(), address, fetcher)
+			return err
+		},
+	},
+	{
+		name:          "config",
+		size:          common.ConfigAccountSize,
+		discriminator: common.ConfigDiscriminator,
+		decode: func(account *rpc.Account) error {
+			_, err := DecodeConfig(account, testProgramID)
+			return err
+		},
+		get: func(fetcher common.AccountFetcher, address solana.PublicKey) error {
+			_, _, err := GetConfigWithSlot(context.Background(), address, fetcher)
+			return err
+		},
+	},
+}
+
+// checkSentinel decodes and fetches account as every kind and expects sentinel back through errors.Is
+func checkSentinel(t *testing.T, sentinel error, account func(kind accountKind) *rpc.Account) {
+	t.Helper()
+
+	for _, kind := range accountKinds {
+		t.Run(kind.name, func(t *testing.T) {
+			if err := kind.decode(account(kind)); !errors.Is(err, sentinel) {
+				t.Fatalf("expected Decode to return %v, got %v", sentinel, err)
+			}
+
+			address := solana.NewWallet().PublicKey()
+			fetcher := &fakeAccountFetcher{accounts: map[solana.PublicKey]*rpc.Account{address: account(kind)}}
+			if err := kind.get(fetcher, address); !errors.Is(err, sentinel) {
+				t.Fatalf("expected Get to return %v, got %v", sentinel, err)
+			}
+		})
+	}
+}
+
+func TestValidAccountsDecode(t *testing.T) {
+	for _, kind := range accountKinds {
+		if err := kind.decode(programAccount(testProgramID, kind.size, kind.discriminator)); err != nil {
+			t.Fatalf("expected a valid %s to decode, got %v", kind.name, err)
+		}
+	}
+}
+
+func TestDecodeErrNotFound(t *testing.T) {
+	checkSentinel(t, common.ErrNotFound, func(kind accountKind) *rpc.Account {
+		return nil
+	})
+
+	// A node answering with an RPC not found error maps to the same sentinel
+	for _, kind := range accountKinds {
+		if err := kind.get(&fakeAccountFetcher{err: rpc.ErrNotFound}, solana.NewWallet().PublicKey()); !errors.Is(err, common.ErrNotFound) {
+			t.Fatalf("expected %s lookups to return ErrNotFound, got %v", kind.name, err)
+		}
+	}
+}
+
+func TestDecodeErrWrongOwner(t *testing.T) {
+	checkSentinel(t, common.ErrWrongOwner, func(kind accountKind) *rpc.Account {
+		// Same bytes as a real account, owned by another program
+		return programAccount(solana.NewWallet().PublicKey(), kind.size, kind.discriminator)
+	})
+}
+
+func TestDecodeErrWrongSize(t *testing.T) {
+	checkSentinel(t, common.ErrWrongSize, func(kind accountKind) *rpc.Account {
+		return programAccount(testProgramID, kind.size+8, kind.discriminator)
+	})
+}
+
+func TestDecodeErrWrongDiscriminator(t *testing.T) {
+	checkSentinel(t, common.ErrWrongDiscriminator, func(kind accountKind) *rpc.Account {
+		// Another account type's discriminator at the right size
+		other := common.PositionDiscriminator
+		if kind.name == "position" {
+			other = common.PoolDiscriminator
+		}
+		return programAccount(testProgramID, kind.size, other)
+	})
+}
+
+func TestDecodeTokenAccountSentinels(t *testing.T) {
+	tests := []struct {
+		name     string
+		account  *rpc.Account
+		sentinel error
+	}{
+		{name: "missing", account: nil, sentinel: common.ErrNotFound},
+		{name: "system account", account: programAccount(solana.SystemProgramID, common.TokenAccountSize, nil), sentinel: common.ErrWrongOwner},
+		{name: "cp_amm account", account: programAccount(testProgramID, common.TokenAccountSize, nil), sentinel: common.ErrWrongOwner},
+		{name: "mint sized", account: programAccount(solana.TokenProgramID, common.MintSize, nil), sentinel: common.ErrWrongSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeVaultAmount(solana.NewWallet().PublicKey(), tt.account); !errors.Is(err, tt.sentinel) {
+				t.Fatalf("expected %v, got %v", tt.sentinel, err)
+			}
+		})
+	}
+
+	for _, owner := range []solana.PublicKey{solana.TokenProgramID, solana.Token2022ProgramID} {
+		if _, err := decodeVaultAmount(solana.NewWallet().PublicKey(), programAccount(owner, common.TokenAccountSize, nil)); err != nil {
+			t.Fatalf("expected a %s token account to decode, got %v", owner, err)
+		}
+	}
+}
+
+func TestValidateMintSentinels(t *testing.T) {
+	tests := []struct {
+		name     string
+		account  *rpc.Account
+		sentinel error
+	}{
+		{name: "missing", account: nil, sentinel: common.ErrNotFound},
+		{name: "system account", account: programAccount(solana.SystemProgramID, common.MintSize, nil), sentinel: common.ErrWrongOwner},
+		{name: "too small", account: programAccount(solana.TokenProgramID, common.MintSize-1, nil), sentinel: common.ErrWrongSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := common.ValidateMint(tt.account); !errors.Is(err, tt.sentinel) {
+				t.Fatalf("expected %v, got %v", tt.sentinel, err)
+			}
+		})
+	}
+
+	if err := common.ValidateMint(programAccount(solana.Token2022ProgramID, common.MintSize, nil)); err != nil {
+		t.Fatalf("expected a Token-2022 mint to validate, got %v", err)
+	}
+}
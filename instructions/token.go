@@ -0,0 +1,42 @@
+package instructions
+
+import (
+	"github.com/dannwee/dbc-go/helpers"
+	"github.com/gagliardetto/solana-go"
+)
+
+// CreateAssociatedTokenAccountIdempotent creates the associated token account of a wallet for a
+// mint of either token program, doing nothing if it already exists
+func CreateAssociatedTokenAccountIdempotent(
+	payer solana.PublicKey,
+	wallet solana.PublicKey,
+	mint solana.PublicKey,
+	tokenProgram solana.PublicKey,
+) (solana.Instruction, error) {
+	associatedTokenAccount, err := helpers.DeriveAssociatedTokenAddress(wallet, mint, tokenProgram)
+	if err != nil {
+		return nil, err
+	}
+
+	acctMeta := solana.AccountMetaSlice{
+		// 1. payer (signer)
+		{PublicKey: payer, IsSigner: true, IsWritable: true},
+		// 2. associated_token_account
+		{PublicKey: associatedTokenAccount, IsSigner: false, IsWritable: true},
+		// 3. wallet
+		{PublicKey: wallet, IsSigner: false, IsWritable: false},
+		// 4. mint
+		{PublicKey: mint, IsSigner: false, IsWritable: false},
+		// 5. system_program
+		{PublicKey: solana.SystemProgramID, IsSigner: false, IsWritable: false},
+		// 6. token_program
+		{PublicKey: tokenProgram, IsSigner: false, IsWritable: false},
+	}
+
+	// 1 selects CreateIdempotent
+	return solana.NewInstruction(
+		solana.SPLAssociatedTokenAccountProgramID,
+		acctMeta,
+		[]byte{1},
+	), nil
+}
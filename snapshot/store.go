@@ -0,0 +1,97 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/dannwee/dbc-go/helpers"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Store persists pool snapshots so fee APR can be estimated from their history
+type Store interface {
+	// Save appends a snapshot
+	Save(ctx context.Context, snapshot common.PoolSnapshot) error
+	// List returns the snapshots of a pool ordered by timestamp
+	List(ctx context.Context, pool solana.PublicKey) ([]common.PoolSnapshot, error)
+}
+
+// MemoryStore keeps snapshots in memory
+type MemoryStore struct {
+	mu        sync.RWMutex
+	snapshots map[solana.PublicKey][]common.PoolSnapshot
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		snapshots: make(map[solana.PublicKey][]common.PoolSnapshot),
+	}
+}
+
+func (s *MemoryStore) Save(ctx context.Context, snapshot common.PoolSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots[snapshot.Pool] = append(s.snapshots[snapshot.Pool], snapshot)
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, pool solana.PublicKey) ([]common.PoolSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshots := append([]common.PoolSnapshot(nil), s.snapshots[pool]...)
+	sortByTimestamp(snapshots)
+	return snapshots, nil
+}
+
+func sortByTimestamp(snapshots []common.PoolSnapshot) {
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Timestamp < snapshots[j].Timestamp
+	})
+}
+
+// EstimateFeeAPR estimates a pool's fee APR from the oldest snapshot at or after since to the latest snapshot
+func EstimateFeeAPR(ctx context.Context, store Store, pool solana.PublicKey, since int64) (*common.FeeAPR, error) {
+	snapshots, err := store.List(ctx, pool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	start, end, err := selectWindow(snapshots, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return helpers.EstimateFeeAPR(start, end)
+}
+
+// EstimatePositionFeeAPR is EstimateFeeAPR for a single position of the pool
+func EstimatePositionFeeAPR(ctx context.Context, store Store, position *common.PositionState, since int64) (*common.PositionFeeAPR, error) {
+	snapshots, err := store.List(ctx, position.Pool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	start, end, err := selectWindow(snapshots, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return helpers.EstimatePositionFeeAPR(start, end, position)
+}
+
+// selectWindow picks the first snapshot at or after since and the latest snapshot
+func selectWindow(snapshots []common.PoolSnapshot, since int64) (common.PoolSnapshot, common.PoolSnapshot, error) {
+	i := sort.Search(len(snapshots), func(i int) bool {
+		return snapshots[i].Timestamp >= since
+	})
+	if len(snapshots)-i < 2 {
+		return common.PoolSnapshot{}, common.PoolSnapshot{}, fmt.Errorf("need at least 2 snapshots since %d, have %d", since, len(snapshots)-i)
+	}
+
+	return snapshots[i], snapshots[len(snapshots)-1], nil
+}
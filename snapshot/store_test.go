@@ -0,0 +1,145 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"lukechampine.com/uint128"
+)
+
+func snapshotsAt(pool solana.PublicKey, timestamps ...int64) []common.PoolSnapshot {
+	snapshots := make([]common.PoolSnapshot, len(timestamps))
+	for i, timestamp := range timestamps {
+		snapshots[i] = common.PoolSnapshot{Pool: pool, Timestamp: timestamp}
+	}
+	return snapshots
+}
+
+func TestSelectWindow(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+
+	tests := []struct {
+		name       string
+		timestamps []int64
+		since      int64
+		start      int64
+		end        int64
+		wantErr    bool
+	}{
+		{name: "no snapshots", timestamps: nil, since: 0, wantErr: true},
+		{name: "one snapshot", timestamps: []int64{100}, since: 0, wantErr: true},
+		{name: "one snapshot in the window", timestamps: []int64{100, 200}, since: 150, wantErr: true},
+		{name: "window after every snapshot", timestamps: []int64{100, 200}, since: 300, wantErr: true},
+		{name: "window before every snapshot", timestamps: []int64{100, 200, 300}, since: 0, start: 100, end: 300},
+		{name: "straddling the window start", timestamps: []int64{100, 200, 300, 400}, since: 150, start: 200, end: 400},
+		{name: "snapshot at the window start", timestamps: []int64{100, 200, 300}, since: 200, start: 200, end: 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := selectWindow(snapshotsAt(pool, tt.timestamps...), tt.since)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d to %d", start.Timestamp, end.Timestamp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to select window: %v", err)
+			}
+			if start.Timestamp != tt.start || end.Timestamp != tt.end {
+				t.Fatalf("expected %d to %d, got %d to %d", tt.start, tt.end, start.Timestamp, end.Timestamp)
+			}
+		})
+	}
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshots.jsonl")
+	store := NewFileStore(path)
+
+	if snapshots, err := store.List(ctx, solana.PublicKey{}); err != nil || snapshots != nil {
+		t.Fatalf("expected a missing file to list nothing, got %v, %v", snapshots, err)
+	}
+
+	pool := solana.NewWallet().PublicKey()
+	other := solana.NewWallet().PublicKey()
+	saved := common.PoolSnapshot{
+		Pool:           pool,
+		Timestamp:      200,
+		Slot:           12_345,
+		SqrtPrice:      uint128.New(7, 1),
+		SqrtMinPrice:   uint128.From64(4295048016),
+		SqrtMaxPrice:   uint128.New(9537527425331189659, 4294886577),
+		Liquidity:      uint128.New(3, 1<<63),
+		CollectFeeMode: common.CollectFeeModeOnlyB,
+		TotalLpAFee:    uint128.From64(11),
+		TotalLpBFee:    uint128.New(0, 2),
+	}
+
+	// Saved out of order and interleaved with another pool
+	for _, snapshot := range []common.PoolSnapshot{
+		saved,
+		{Pool: other, Timestamp: 150},
+		{Pool: pool, Timestamp: 100},
+	} {
+		if err := store.Save(ctx, snapshot); err != nil {
+			t.Fatalf("failed to save snapshot: %v", err)
+		}
+	}
+
+	// A second store on the same file reads what the first wrote
+	snapshots, err := NewFileStore(path).List(ctx, pool)
+	if err != nil {
+		t.Fatalf("failed to list snapshots: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Timestamp != 100 {
+		t.Fatalf("expected the 2 snapshots of the pool ordered by timestamp, got %+v", snapshots)
+	}
+	if snapshots[1] != saved {
+		t.Fatalf("expected %+v back, got %+v", saved, snapshots[1])
+	}
+
+	if err := os.WriteFile(path, []byte("{not json}\n"), 0o644); err != nil {
+		t.Fatalf("failed to corrupt snapshot file: %v", err)
+	}
+	if _, err := store.List(ctx, pool); err == nil {
+		t.Fatal("expected a corrupt line to be reported")
+	}
+}
+
+func TestEstimateFeeAPRFromStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	pool := solana.NewWallet().PublicKey()
+
+	oneQ64 := uint128.From64(1).Lsh(common.ScaleOffset)
+	for i, timestamp := range []int64{0, 86_400, 2 * 86_400} {
+		snapshot := common.PoolSnapshot{
+			Pool:         pool,
+			Timestamp:    timestamp,
+			SqrtPrice:    oneQ64,
+			SqrtMinPrice: oneQ64.Rsh(1),
+			SqrtMaxPrice: oneQ64.Mul64(2),
+			Liquidity:    uint128.From64(2_000).Lsh(common.ScaleOffset),
+			TotalLpBFee:  uint128.From64(uint64(i) * 20),
+		}
+		if err := store.Save(ctx, snapshot); err != nil {
+			t.Fatalf("failed to save snapshot: %v", err)
+		}
+	}
+
+	// Only the last day falls in the window
+	apr, err := EstimateFeeAPR(ctx, store, pool, 1)
+	if err != nil {
+		t.Fatalf("failed to estimate fee APR: %v", err)
+	}
+	if apr.ElapsedSeconds != 86_400 || !apr.LpBFee.Equals64(20) {
+		t.Fatalf("expected 20 of fees over the last day, got %s over %d seconds", apr.LpBFee, apr.ElapsedSeconds)
+	}
+}
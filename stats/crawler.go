@@ -0,0 +1,114 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/dannwee/dbc-go/damm"
+	"github.com/dannwee/dbc-go/helpers"
+	"github.com/dannwee/dbc-go/instructions"
+	"github.com/gagliardetto/solana-go"
+	"lukechampine.com/uint128"
+)
+
+// CrawlOptions limit the load a crawl puts on the RPC endpoint
+type CrawlOptions struct {
+	// Request budget, 0 for no limit
+	RequestsPerSecond int
+}
+
+// Crawl lists every pool and every position of the program and aggregates them, with one
+// getProgramAccounts request each. Positions are grouped by pool, those of a pool created
+// between the two requests are skipped.
+func Crawl(ctx context.Context, rpcClient common.AccountFetcher, crawlOpts CrawlOptions, opts ...common.FetchOption) (*common.ProtocolStats, error) {
+	options := common.NewFetchOptions(opts...)
+	rpcClient = damm.NewRateLimitedFetcher(rpcClient, crawlOpts.RequestsPerSecond)
+
+	pools, err := instructions.GetAllPools(ctx, rpcClient, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &common.ProtocolStats{
+		ProgramID:                options.ProgramID,
+		CrawledAt:                time.Now().UTC(),
+		PoolsByStatus:            map[string]int{},
+		PoolsByCollectFeeMode:    map[string]int{},
+		TotalLiquidity:           new(big.Int),
+		PermanentLockedLiquidity: new(big.Int),
+		VestedLiquidity:          new(big.Int),
+		UnlockedLiquidity:        new(big.Int),
+		Fees:                     map[string]*common.MintFees{},
+	}
+	positions, err := instructions.GetAllPositions(ctx, rpcClient, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	positionsByPool := make(map[solana.PublicKey][]*common.PositionState)
+	for i := range positions {
+		state := &positions[i].PositionState
+		positionsByPool[state.Pool] = append(positionsByPool[state.Pool], state)
+	}
+
+	for _, pool := range pools {
+		addPool(stats, &pool.PoolState)
+		for _, position := range positionsByPool[pool.Pool] {
+			addPosition(stats, position)
+		}
+	}
+
+	return stats, nil
+}
+
+// WriteJSON writes stats as indented JSON. Map keys are sorted, so runs can be diffed.
+func WriteJSON(w io.Writer, stats *common.ProtocolStats) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(stats)
+}
+
+func addPool(stats *common.ProtocolStats, pool *common.Pool) {
+	stats.Pools++
+
+	switch pool.PoolStatus {
+	case common.PoolStatusEnabled:
+		stats.PoolsByStatus["enabled"]++
+	case common.PoolStatusDisabled:
+		stats.PoolsByStatus["disabled"]++
+	default:
+		stats.PoolsByStatus[fmt.Sprintf("unknown(%d)", pool.PoolStatus)]++
+	}
+	stats.PoolsByCollectFeeMode[pool.CollectFeeMode.String()]++
+	if helpers.IsDynamicFeeEnabled(pool.PoolFees.DynamicFee) {
+		stats.PoolsWithDynamicFee++
+	}
+
+	stats.TotalLiquidity.Add(stats.TotalLiquidity, pool.Liquidity.Big())
+
+	addFees(stats, pool.TokenAMint.String(), pool.Metrics.TotalLpAFee, pool.Metrics.TotalProtocolAFee, pool.Metrics.TotalPartnerAFee)
+	addFees(stats, pool.TokenBMint.String(), pool.Metrics.TotalLpBFee, pool.Metrics.TotalProtocolBFee, pool.Metrics.TotalPartnerBFee)
+}
+
+func addFees(stats *common.ProtocolStats, mint string, lpFee uint128.Uint128, protocolFee uint64, partnerFee uint64) {
+	fees, ok := stats.Fees[mint]
+	if !ok {
+		fees = &common.MintFees{LpFee: new(big.Int), ProtocolFee: new(big.Int), PartnerFee: new(big.Int)}
+		stats.Fees[mint] = fees
+	}
+	fees.LpFee.Add(fees.LpFee, lpFee.Big())
+	fees.ProtocolFee.Add(fees.ProtocolFee, new(big.Int).SetUint64(protocolFee))
+	fees.PartnerFee.Add(fees.PartnerFee, new(big.Int).SetUint64(partnerFee))
+}
+
+func addPosition(stats *common.ProtocolStats, position *common.PositionState) {
+	stats.Positions++
+	stats.PermanentLockedLiquidity.Add(stats.PermanentLockedLiquidity, position.PermanentLockedLiquidity.Big())
+	stats.VestedLiquidity.Add(stats.VestedLiquidity, position.VestedLiquidity.Big())
+	stats.UnlockedLiquidity.Add(stats.UnlockedLiquidity, position.UnlockedLiquidity.Big())
+}
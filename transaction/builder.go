@@ -0,0 +1,222 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// DefaultComputeUnitLimit is used when Builder.ComputeUnitLimit is zero
+	DefaultComputeUnitLimit uint32 = 200_000
+	// MaxComputeUnitLimit is the most compute units a transaction can request
+	MaxComputeUnitLimit uint32 = 1_400_000
+	// DefaultComputeUnitMargin is added on top of the units consumed in simulation
+	DefaultComputeUnitMargin = 0.1
+)
+
+// maxPriorityFeeAccounts is the most accounts getRecentPrioritizationFees accepts
+const maxPriorityFeeAccounts = 128
+
+// RPCClient is the subset of RPC methods transactions are assembled with. *rpc.Client satisfies it.
+type RPCClient interface {
+	GetLatestBlockhash(ctx context.Context, commitment rpc.CommitmentType) (*rpc.GetLatestBlockhashResult, error)
+	GetRecentPrioritizationFees(ctx context.Context, accounts solana.PublicKeySlice) ([]rpc.PriorizationFeeResult, error)
+	SimulateTransactionWithOpts(ctx context.Context, transaction *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResponse, error)
+	GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey, opts *rpc.GetMultipleAccountsOpts) (*rpc.GetMultipleAccountsResult, error)
+}
+
+var _ RPCClient = (*rpc.Client)(nil)
+
+// PriorityFeeOptions control how the compute unit price is picked from recent prioritization fees
+type PriorityFeeOptions struct {
+	// Percentile of the recent fees to pay, from 0 to 100
+	Percentile float64
+	// MinMicroLamports is the lowest price paid per compute unit
+	MinMicroLamports uint64
+	// MaxMicroLamports is the highest price paid per compute unit, 0 for no cap
+	MaxMicroLamports uint64
+}
+
+// DefaultPriorityFeeOptions pays the 75th percentile of recent fees, capped at 1 lamport per compute unit
+var DefaultPriorityFeeOptions = PriorityFeeOptions{
+	Percentile:       75,
+	MinMicroLamports: 0,
+	MaxMicroLamports: 1_000_000,
+}
+
+// Transaction is an assembled transaction with the compute budget it was given
+type Transaction struct {
+	Transaction *solana.Transaction
+	// LastValidBlockHeight is the last block height the transaction's blockhash is accepted at
+	LastValidBlockHeight uint64
+	ComputeUnitLimit     uint32
+	// ComputeUnitPrice is in micro-lamports per compute unit
+	ComputeUnitPrice uint64
+}
+
+// Builder assembles cp_amm instructions into transactions, prepending SetComputeUnitLimit and
+// SetComputeUnitPrice. Instructions passed to it must not set a compute budget themselves.
+type Builder struct {
+	client RPCClient
+	payer  solana.PublicKey
+
+	// Commitment is used to fetch the blockhash
+	Commitment rpc.CommitmentType
+	// SimulationCommitment is the bank transactions are simulated against, Confirmed if empty
+	SimulationCommitment rpc.CommitmentType
+	// ProgramID is the cp_amm program whose error codes are decoded
+	ProgramID solana.PublicKey
+	// ComputeUnitLimit is requested by Build, DefaultComputeUnitLimit if zero and clamped to MaxComputeUnitLimit
+	ComputeUnitLimit uint32
+	// ComputeUnitMargin is the fraction BuildSimulated adds to the consumed compute units
+	ComputeUnitMargin float64
+	// PriorityFee selects the compute unit price
+	PriorityFee PriorityFeeOptions
+	// LookupTables, when set, makes the builder compile v0 messages against the cached tables worth using
+	LookupTables *LookupTableCache
+}
+
+// NewBuilder creates a builder for transactions paid by payer
+func NewBuilder(client RPCClient, payer solana.PublicKey) *Builder {
+	return &Builder{
+		client:               client,
+		payer:                payer,
+		Commitment:           rpc.CommitmentFinalized,
+		SimulationCommitment: rpc.CommitmentConfirmed,
+		ProgramID:            solana.MustPublicKeyFromBase58(common.DammV2ProgramID),
+		ComputeUnitMargin:    DefaultComputeUnitMargin,
+		PriorityFee:          DefaultPriorityFeeOptions,
+	}
+}
+
+// Build prepends the compute budget instructions to instructions and sets a fresh blockhash.
+// The returned transaction is not signed.
+func (b *Builder) Build(ctx context.Context, instructions ...solana.Instruction) (*Transaction, error) {
+	limit := b.ComputeUnitLimit
+	if limit == 0 {
+		limit = DefaultComputeUnitLimit
+	}
+	if limit > MaxComputeUnitLimit {
+		limit = MaxComputeUnitLimit
+	}
+
+	return b.build(ctx, instructions, limit)
+}
+
+// build assembles instructions with limit, a fresh blockhash and an estimated price
+func (b *Builder) build(ctx context.Context, instructions []solana.Instruction, limit uint32) (*Transaction, error) {
+	price, err := b.EstimatePriorityFee(ctx, WritableAccounts(instructions))
+	if err != nil {
+		return nil, err
+	}
+
+	blockhash, err := b.client.GetLatestBlockhash(ctx, b.Commitment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest blockhash: %w", err)
+	}
+
+	tx, err := b.assemble(instructions, blockhash.Value.Blockhash, blockhash.Context.Slot, limit, price)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transaction{
+		Transaction:          tx,
+		LastValidBlockHeight: blockhash.Value.LastValidBlockHeight,
+		ComputeUnitLimit:     limit,
+		ComputeUnitPrice:     price,
+	}, nil
+}
+
+// EstimatePriorityFee returns the compute unit price in micro-lamports, sampled from the recent
+// prioritization fees of accounts and clamped to the builder's caps
+func (b *Builder) EstimatePriorityFee(ctx context.Context, accounts solana.PublicKeySlice) (uint64, error) {
+	if len(accounts) > maxPriorityFeeAccounts {
+		accounts = accounts[:maxPriorityFeeAccounts]
+	}
+
+	fees, err := b.client.GetRecentPrioritizationFees(ctx, accounts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get recent prioritization fees: %w", err)
+	}
+
+	samples := make([]uint64, len(fees))
+	for i, fee := range fees {
+		samples[i] = fee.PrioritizationFee
+	}
+
+	price := percentile(samples, b.PriorityFee.Percentile)
+	if price < b.PriorityFee.MinMicroLamports {
+		price = b.PriorityFee.MinMicroLamports
+	}
+	if b.PriorityFee.MaxMicroLamports > 0 && price > b.PriorityFee.MaxMicroLamports {
+		price = b.PriorityFee.MaxMicroLamports
+	}
+	return price, nil
+}
+
+// assemble creates the transaction with the compute budget instructions first, as a v0 message if
+// lookup tables are selected. slot is the latest slot known to have been reached.
+func (b *Builder) assemble(instructions []solana.Instruction, blockhash solana.Hash, slot uint64, limit uint32, price uint64) (*solana.Transaction, error) {
+	// Build, not ValidateAndBuild, which rejects a zero price when no recent fees were paid
+	all := make([]solana.Instruction, 0, len(instructions)+2)
+	all = append(all,
+		computebudget.NewSetComputeUnitLimitInstruction(limit).Build(),
+		computebudget.NewSetComputeUnitPriceInstruction(price).Build(),
+	)
+	all = append(all, instructions...)
+
+	opts := []solana.TransactionOption{solana.TransactionPayer(b.payer)}
+	if b.LookupTables != nil {
+		if tables := b.LookupTables.Select(b.payer, all, slot); len(tables) > 0 {
+			opts = append(opts, solana.TransactionAddressTables(tables))
+		}
+	}
+
+	tx, err := solana.NewTransaction(all, blockhash, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// WritableAccounts returns the accounts instructions write to, excluding signers, in first-seen order.
+// These are the accounts whose write locks the transaction competes for, e.g. the pool vaults and position.
+func WritableAccounts(instructions []solana.Instruction) solana.PublicKeySlice {
+	seen := make(map[solana.PublicKey]bool)
+	var accounts solana.PublicKeySlice
+	for _, instruction := range instructions {
+		for _, meta := range instruction.Accounts() {
+			if !meta.IsWritable || meta.IsSigner || seen[meta.PublicKey] {
+				continue
+			}
+			seen[meta.PublicKey] = true
+			accounts = append(accounts, meta.PublicKey)
+		}
+	}
+	return accounts
+}
+
+// percentile returns the nearest-rank percentile of samples, 0 if there are none
+func percentile(samples []uint64, p float64) uint64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	if p <= 0 {
+		return samples[0]
+	}
+	if p >= 100 {
+		return samples[len(samples)-1]
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(samples))))
+	return samples[rank-1]
+}
@@ -0,0 +1,182 @@
+package transaction
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestPercentile(t *testing.T) {
+	samples := []uint64{50, 10, 40, 20, 30}
+
+	tests := []struct {
+		name    string
+		samples []uint64
+		p       float64
+		want    uint64
+	}{
+		{name: "empty", samples: nil, p: 75, want: 0},
+		{name: "p0 is the minimum", samples: samples, p: 0, want: 10},
+		{name: "negative p is the minimum", samples: samples, p: -5, want: 10},
+		{name: "p100 is the maximum", samples: samples, p: 100, want: 50},
+		{name: "p above 100 is the maximum", samples: samples, p: 150, want: 50},
+		{name: "nearest rank rounds up", samples: samples, p: 41, want: 30},
+		{name: "exact rank", samples: samples, p: 40, want: 20},
+		{name: "median", samples: samples, p: 50, want: 30},
+		{name: "single sample", samples: []uint64{7}, p: 1, want: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := append([]uint64(nil), tt.samples...)
+			if got := percentile(input, tt.p); got != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEstimatePriorityFee(t *testing.T) {
+	fees := []uint64{0, 100, 200, 300, 400}
+
+	tests := []struct {
+		name    string
+		fees    []uint64
+		options PriorityFeeOptions
+		want    uint64
+	}{
+		{name: "percentile", fees: fees, options: PriorityFeeOptions{Percentile: 75}, want: 300},
+		{name: "raised to the minimum", fees: fees, options: PriorityFeeOptions{Percentile: 20, MinMicroLamports: 50}, want: 50},
+		{name: "capped at the maximum", fees: fees, options: PriorityFeeOptions{Percentile: 100, MaxMicroLamports: 250}, want: 250},
+		{name: "zero maximum is uncapped", fees: fees, options: PriorityFeeOptions{Percentile: 100}, want: 400},
+		{name: "no recent fees pays the minimum", fees: nil, options: PriorityFeeOptions{Percentile: 75, MinMicroLamports: 10}, want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := NewBuilder(&fakeRPCClient{fees: tt.fees}, solana.NewWallet().PublicKey())
+			builder.PriorityFee = tt.options
+
+			price, err := builder.EstimatePriorityFee(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("failed to estimate priority fee: %v", err)
+			}
+			if price != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, price)
+			}
+		})
+	}
+}
+
+func TestEstimatePriorityFeeTruncatesAccounts(t *testing.T) {
+	accounts := make(solana.PublicKeySlice, maxPriorityFeeAccounts+10)
+	for i := range accounts {
+		accounts[i] = solana.NewWallet().PublicKey()
+	}
+	client := &fakeRPCClient{}
+	builder := NewBuilder(client, solana.NewWallet().PublicKey())
+
+	if _, err := builder.EstimatePriorityFee(context.Background(), accounts); err != nil {
+		t.Fatalf("failed to estimate priority fee: %v", err)
+	}
+	if len(client.feeAccounts) != maxPriorityFeeAccounts {
+		t.Fatalf("expected %d accounts, got %d", maxPriorityFeeAccounts, len(client.feeAccounts))
+	}
+	for i, account := range client.feeAccounts {
+		if !account.Equals(accounts[i]) {
+			t.Fatalf("expected the first %d accounts in order, account %d differs", maxPriorityFeeAccounts, i)
+		}
+	}
+}
+
+func TestWritableAccounts(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	signer := solana.NewWallet().PublicKey()
+	pool := solana.NewWallet().PublicKey()
+	vaultA := solana.NewWallet().PublicKey()
+	vaultB := solana.NewWallet().PublicKey()
+	config := solana.NewWallet().PublicKey()
+
+	instructions := []solana.Instruction{
+		solana.NewInstruction(solana.SystemProgramID, solana.AccountMetaSlice{
+			{PublicKey: payer, IsSigner: true, IsWritable: true},
+			{PublicKey: pool, IsWritable: true},
+			{PublicKey: config},
+			{PublicKey: vaultB, IsWritable: true},
+		}, nil),
+		solana.NewInstruction(solana.SystemProgramID, solana.AccountMetaSlice{
+			{PublicKey: signer, IsSigner: true, IsWritable: true},
+			{PublicKey: vaultA, IsWritable: true},
+			{PublicKey: pool, IsWritable: true},
+			{PublicKey: vaultB, IsWritable: true},
+		}, nil),
+	}
+
+	want := solana.PublicKeySlice{pool, vaultB, vaultA}
+	got := WritableAccounts(instructions)
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if !got[i].Equals(want[i]) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestBuildPrependsComputeBudget(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	builder := NewBuilder(&fakeRPCClient{fees: []uint64{1_000, 2_000}}, payer)
+	builder.ComputeUnitLimit = 2 * MaxComputeUnitLimit
+	builder.PriorityFee = PriorityFeeOptions{Percentile: 100}
+
+	instruction := testInstruction(payer, solana.NewWallet().PublicKey())
+	tx, err := builder.Build(context.Background(), instruction)
+	if err != nil {
+		t.Fatalf("failed to build transaction: %v", err)
+	}
+
+	message := tx.Transaction.Message
+	if len(message.Instructions) != 3 {
+		t.Fatalf("expected 3 instructions, got %d", len(message.Instructions))
+	}
+	for i := 0; i < 2; i++ {
+		program, err := message.Program(message.Instructions[i].ProgramIDIndex)
+		if err != nil || !program.Equals(solana.ComputeBudget) {
+			t.Fatalf("expected instruction %d to be a compute budget instruction, got %s", i, program)
+		}
+	}
+	if program, _ := message.Program(message.Instructions[2].ProgramIDIndex); !program.Equals(solana.SystemProgramID) {
+		t.Fatalf("expected the caller's instruction last, got %s", program)
+	}
+
+	if limit := computeUnitLimit(t, tx.Transaction); limit != MaxComputeUnitLimit || tx.ComputeUnitLimit != MaxComputeUnitLimit {
+		t.Fatalf("expected the limit clamped to %d, got %d", MaxComputeUnitLimit, limit)
+	}
+
+	data := message.Instructions[1].Data
+	if len(data) != 9 || data[0] != 3 {
+		t.Fatalf("expected SetComputeUnitPrice second, got %v", data)
+	}
+	if price := binary.LittleEndian.Uint64(data[1:]); price != 2_000 || tx.ComputeUnitPrice != 2_000 {
+		t.Fatalf("expected a price of 2000, got %d", price)
+	}
+	if !message.RecentBlockhash.Equals(solana.Hash{1}) || tx.LastValidBlockHeight != 1000 {
+		t.Fatalf("expected the latest blockhash, got %s", message.RecentBlockhash)
+	}
+}
+
+func TestBuildAcceptsZeroPrice(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	builder := NewBuilder(&fakeRPCClient{}, payer)
+
+	tx, err := builder.Build(context.Background(), testInstruction(payer))
+	if err != nil {
+		t.Fatalf("failed to build transaction without recent fees: %v", err)
+	}
+	if tx.ComputeUnitPrice != 0 || tx.ComputeUnitLimit != DefaultComputeUnitLimit {
+		t.Fatalf("expected the default limit at price 0, got %d at %d", tx.ComputeUnitLimit, tx.ComputeUnitPrice)
+	}
+}
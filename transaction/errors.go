@@ -0,0 +1,132 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+)
+
+// TransactionError is a transaction that failed in simulation or on chain, decoded from the RPC error
+type TransactionError struct {
+	// Err is the error as returned by the RPC node
+	Err interface{}
+	// InstructionIndex is the failed instruction, counting the compute budget instructions, -1 if none failed
+	InstructionIndex int
+	// Program is the program of the failed instruction, zero if no instruction failed
+	Program solana.PublicKey
+	// Code is the custom error code returned by Program, nil for built-in errors
+	Code *uint32
+	// Name is the error name, e.g. ExceededSlippage for a cp_amm error or InvalidAccountData
+	Name string
+	Logs []string
+}
+
+func (e *TransactionError) Error() string {
+	name := e.Name
+	if e.Code != nil {
+		name = fmt.Sprintf("%s (%d)", name, *e.Code)
+	}
+	if e.InstructionIndex < 0 {
+		return fmt.Sprintf("transaction failed: %s", name)
+	}
+	return fmt.Sprintf("instruction %d of program %s failed: %s", e.InstructionIndex, e.Program, name)
+}
+
+// DecodeTransactionError decodes the err field of a simulation or transaction status.
+// Custom codes of programID are named after the cp_amm errors, other Anchor errors are named from the logs.
+func DecodeTransactionError(tx *solana.Transaction, err interface{}, logs []string, programID solana.PublicKey) *TransactionError {
+	decoded := &TransactionError{Err: err, InstructionIndex: -1, Logs: logs}
+
+	switch value := err.(type) {
+	case string:
+		decoded.Name = value
+		return decoded
+	case map[string]interface{}:
+		instructionError, ok := value["InstructionError"].([]interface{})
+		if !ok || len(instructionError) != 2 {
+			decoded.Name = errorName(value)
+			return decoded
+		}
+
+		index, ok := toUint32(instructionError[0])
+		if !ok {
+			decoded.Name = errorName(value)
+			return decoded
+		}
+		decoded.InstructionIndex = int(index)
+		if tx != nil && decoded.InstructionIndex < len(tx.Message.Instructions) {
+			decoded.Program, _ = tx.Message.Program(tx.Message.Instructions[decoded.InstructionIndex].ProgramIDIndex)
+		}
+
+		switch detail := instructionError[1].(type) {
+		case string:
+			decoded.Name = detail
+		case map[string]interface{}:
+			if code, ok := toUint32(detail["Custom"]); ok {
+				decoded.Code = &code
+				decoded.Name = customErrorName(decoded.Program, code, logs, programID)
+			} else {
+				decoded.Name = errorName(detail)
+			}
+		default:
+			decoded.Name = fmt.Sprint(detail)
+		}
+		return decoded
+	default:
+		decoded.Name = fmt.Sprint(err)
+		return decoded
+	}
+}
+
+// customErrorName names a custom error code, falling back to the Anchor error logged by the program
+func customErrorName(program solana.PublicKey, code uint32, logs []string, programID solana.PublicKey) string {
+	if program.Equals(programID) {
+		if name, ok := common.ProgramErrorName(code); ok {
+			return name
+		}
+	}
+
+	// Anchor logs "AnchorError ... Error Code: <name>. Error Number: <code>. ..."
+	for _, log := range logs {
+		start := strings.Index(log, "Error Code: ")
+		if start < 0 {
+			continue
+		}
+		name := log[start+len("Error Code: "):]
+		if end := strings.IndexByte(name, '.'); end >= 0 {
+			name = name[:end]
+		}
+		return name
+	}
+
+	return "Custom"
+}
+
+// errorName returns the single key of an error object such as {"InsufficientFundsForRent": {...}}
+func errorName(value map[string]interface{}) string {
+	if len(value) == 1 {
+		for name := range value {
+			return name
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+func toUint32(value interface{}) (uint32, bool) {
+	switch number := value.(type) {
+	case float64:
+		return uint32(number), number >= 0
+	case json.Number:
+		n, err := number.Int64()
+		return uint32(n), err == nil && n >= 0
+	case int:
+		return uint32(number), number >= 0
+	case uint32:
+		return number, true
+	default:
+		return 0, false
+	}
+}
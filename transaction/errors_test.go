@@ -0,0 +1,146 @@
+package transaction
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestDecodeTransactionError(t *testing.T) {
+	programID := solana.MustPublicKeyFromBase58(common.DammV2ProgramID)
+	otherProgram := solana.NewWallet().PublicKey()
+	payer := solana.NewWallet().PublicKey()
+
+	tx, err := solana.NewTransaction([]solana.Instruction{
+		solana.NewInstruction(solana.SystemProgramID, solana.AccountMetaSlice{{PublicKey: payer, IsSigner: true, IsWritable: true}}, nil),
+		solana.NewInstruction(programID, solana.AccountMetaSlice{}, nil),
+		solana.NewInstruction(otherProgram, solana.AccountMetaSlice{}, nil),
+	}, solana.Hash{1}, solana.TransactionPayer(payer))
+	if err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	instructionError := func(index interface{}, detail interface{}) map[string]interface{} {
+		return map[string]interface{}{"InstructionError": []interface{}{index, detail}}
+	}
+	custom := func(code interface{}) map[string]interface{} {
+		return map[string]interface{}{"Custom": code}
+	}
+	code := func(code uint32) *uint32 { return &code }
+
+	tests := []struct {
+		name    string
+		err     interface{}
+		logs    []string
+		index   int
+		program solana.PublicKey
+		code    *uint32
+		want    string
+	}{
+		{
+			name:  "string error",
+			err:   "BlockhashNotFound",
+			index: -1,
+			want:  "BlockhashNotFound",
+		},
+		{
+			name:  "transaction error object",
+			err:   map[string]interface{}{"InsufficientFundsForRent": map[string]interface{}{"account_index": float64(2)}},
+			index: -1,
+			want:  "InsufficientFundsForRent",
+		},
+		{
+			name:    "built-in instruction error",
+			err:     instructionError(float64(0), "InvalidAccountData"),
+			index:   0,
+			program: solana.SystemProgramID,
+			want:    "InvalidAccountData",
+		},
+		{
+			name:    "cp_amm custom code",
+			err:     instructionError(float64(1), custom(float64(6002))),
+			index:   1,
+			program: programID,
+			code:    code(6002),
+			want:    "ExceededSlippage",
+		},
+		{
+			name:    "cp_amm custom code as json.Number",
+			err:     instructionError(json.Number("1"), custom(json.Number("6002"))),
+			index:   1,
+			program: programID,
+			code:    code(6002),
+			want:    "ExceededSlippage",
+		},
+		{
+			name: "custom code of another program named from the Anchor log",
+			err:  instructionError(float64(2), custom(float64(6002))),
+			logs: []string{
+				"Program log: Instruction: Swap",
+				"Program log: AnchorError occurred. Error Code: SlippageToleranceExceeded. Error Number: 6002. Error Message: Slippage tolerance exceeded.",
+			},
+			index:   2,
+			program: otherProgram,
+			code:    code(6002),
+			want:    "SlippageToleranceExceeded",
+		},
+		{
+			name:    "custom code of another program without an Anchor log",
+			err:     instructionError(float64(2), custom(float64(1))),
+			index:   2,
+			program: otherProgram,
+			code:    code(1),
+			want:    "Custom",
+		},
+		{
+			name:  "negative instruction index",
+			err:   instructionError(float64(-1), "InvalidAccountData"),
+			index: -1,
+			want:  "InstructionError",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded := DecodeTransactionError(tx, tt.err, tt.logs, programID)
+			if decoded.Name != tt.want {
+				t.Fatalf("expected name %q, got %q", tt.want, decoded.Name)
+			}
+			if decoded.InstructionIndex != tt.index {
+				t.Fatalf("expected instruction %d, got %d", tt.index, decoded.InstructionIndex)
+			}
+			if !decoded.Program.Equals(tt.program) {
+				t.Fatalf("expected program %s, got %s", tt.program, decoded.Program)
+			}
+			if (decoded.Code == nil) != (tt.code == nil) || (tt.code != nil && *decoded.Code != *tt.code) {
+				t.Fatalf("expected code %v, got %v", tt.code, decoded.Code)
+			}
+		})
+	}
+}
+
+func TestToUint32(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  uint32
+		ok    bool
+	}{
+		{value: float64(6002), want: 6002, ok: true},
+		{value: json.Number("6002"), want: 6002, ok: true},
+		{value: json.Number("x"), ok: false},
+		{value: 3, want: 3, ok: true},
+		{value: uint32(7), want: 7, ok: true},
+		{value: float64(-1), ok: false},
+		{value: "6002", ok: false},
+		{value: nil, ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := toUint32(tt.value)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Fatalf("toUint32(%#v): expected %d, %v, got %d, %v", tt.value, tt.want, tt.ok, got, ok)
+		}
+	}
+}
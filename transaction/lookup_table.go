@@ -0,0 +1,281 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/dannwee/dbc-go/instructions"
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// maxExtendAddresses is how many addresses one ExtendLookupTable instruction appends,
+// small enough for a legacy transaction that also creates the table
+const maxExtendAddresses = 20
+
+// LookupTableClient is the subset of RPC methods lookup tables are managed with. *rpc.Client satisfies it.
+type LookupTableClient interface {
+	GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey, opts *rpc.GetMultipleAccountsOpts) (*rpc.GetMultipleAccountsResult, error)
+	GetSlot(ctx context.Context, commitment rpc.CommitmentType) (uint64, error)
+}
+
+var _ LookupTableClient = (*rpc.Client)(nil)
+
+// LookupTableCache holds the contents of address lookup tables a Builder compiles v0 messages against
+type LookupTableCache struct {
+	client LookupTableClient
+
+	// Commitment is used to read tables and the slot new tables are derived from
+	Commitment rpc.CommitmentType
+
+	mu     sync.RWMutex
+	tables map[solana.PublicKey]*lookupTable
+}
+
+// lookupTable is a cached table. Addresses from lastExtendedStartIndex on were appended in
+// lastExtendedSlot and cannot be used before the slot after it.
+type lookupTable struct {
+	addresses              solana.PublicKeySlice
+	lastExtendedSlot       uint64
+	lastExtendedStartIndex int
+}
+
+// usable returns the addresses a transaction processed at slot can reference
+func (t *lookupTable) usable(slot uint64) solana.PublicKeySlice {
+	if slot <= t.lastExtendedSlot && t.lastExtendedStartIndex < len(t.addresses) {
+		return t.addresses[:t.lastExtendedStartIndex]
+	}
+	return t.addresses
+}
+
+// NewLookupTableCache creates an empty cache
+func NewLookupTableCache(client LookupTableClient) *LookupTableCache {
+	return &LookupTableCache{
+		client:     client,
+		Commitment: rpc.CommitmentFinalized,
+		tables:     make(map[solana.PublicKey]*lookupTable),
+	}
+}
+
+// Load reads tables and caches their addresses, replacing what was cached.
+// Deactivated tables are dropped from the cache, as transactions can no longer use them.
+func (c *LookupTableCache) Load(ctx context.Context, tables ...solana.PublicKey) error {
+	programID := solana.MustPublicKeyFromBase58(common.AddressLookupTableProgram)
+
+	for start := 0; start < len(tables); start += common.MaxMultipleAccounts {
+		end := start + common.MaxMultipleAccounts
+		if end > len(tables) {
+			end = len(tables)
+		}
+
+		result, err := c.client.GetMultipleAccountsWithOpts(ctx, tables[start:end], &rpc.GetMultipleAccountsOpts{
+			Commitment: c.Commitment,
+			Encoding:   solana.EncodingBase64,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get lookup tables: %w", err)
+		}
+
+		for i, table := range tables[start:end] {
+			var account *rpc.Account
+			if i < len(result.Value) {
+				account = result.Value[i]
+			}
+			if account == nil {
+				return fmt.Errorf("lookup table %s: %w", table, common.ErrNotFound)
+			}
+			if !account.Owner.Equals(programID) {
+				return fmt.Errorf("invalid lookup table %s: %w: owned by %s", table, common.ErrWrongOwner, account.Owner)
+			}
+
+			state, err := addresslookuptable.DecodeAddressLookupTableState(account.Data.GetBinary())
+			if err != nil {
+				return fmt.Errorf("invalid lookup table %s: %w", table, err)
+			}
+
+			if state.DeactivationSlot != math.MaxUint64 {
+				c.Remove(table)
+				continue
+			}
+			c.mu.Lock()
+			c.tables[table] = &lookupTable{
+				addresses:              append(solana.PublicKeySlice(nil), state.Addresses...),
+				lastExtendedSlot:       state.LastExtendedSlot,
+				lastExtendedStartIndex: int(state.LastExtendedSlotStartIndex),
+			}
+			c.mu.Unlock()
+		}
+	}
+
+	return nil
+}
+
+// Add caches the contents of a table as usable right away. For a table that was just extended,
+// Load it instead so the new addresses are only used from the slot after the extension.
+func (c *LookupTableCache) Add(table solana.PublicKey, addresses solana.PublicKeySlice) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables[table] = &lookupTable{addresses: append(solana.PublicKeySlice(nil), addresses...)}
+}
+
+// Remove drops a table from the cache
+func (c *LookupTableCache) Remove(table solana.PublicKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tables, table)
+}
+
+// Tables returns a copy of the cached tables
+func (c *LookupTableCache) Tables() map[solana.PublicKey]solana.PublicKeySlice {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tables := make(map[solana.PublicKey]solana.PublicKeySlice, len(c.tables))
+	for table, cached := range c.tables {
+		tables[table] = append(solana.PublicKeySlice(nil), cached.addresses...)
+	}
+	return tables
+}
+
+// Select picks the cached tables worth using for instructions in a transaction processed after slot.
+// Signers and invoked programs must stay in the message, every other account found in a table saves
+// 31 bytes, while each table used costs 34 bytes. Tables are picked greedily by how many remaining
+// accounts they cover, as long as they cover at least two. Addresses appended in slot or later are ignored.
+func (c *LookupTableCache) Select(payer solana.PublicKey, instructions []solana.Instruction, slot uint64) map[solana.PublicKey]solana.PublicKeySlice {
+	static := map[solana.PublicKey]bool{payer: true}
+	for _, instruction := range instructions {
+		static[instruction.ProgramID()] = true
+		for _, meta := range instruction.Accounts() {
+			if meta.IsSigner {
+				static[meta.PublicKey] = true
+			}
+		}
+	}
+
+	uncovered := make(map[solana.PublicKey]bool)
+	for _, instruction := range instructions {
+		for _, meta := range instruction.Accounts() {
+			if !static[meta.PublicKey] {
+				uncovered[meta.PublicKey] = true
+			}
+		}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Sorted so the selection does not depend on map order
+	usable := make(map[solana.PublicKey]solana.PublicKeySlice, len(c.tables))
+	candidates := make([]solana.PublicKey, 0, len(c.tables))
+	for table, cached := range c.tables {
+		usable[table] = cached.usable(slot)
+		candidates = append(candidates, table)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].String() < candidates[j].String() })
+
+	selected := make(map[solana.PublicKey]solana.PublicKeySlice)
+	for len(uncovered) > 0 {
+		var best solana.PublicKey
+		bestCount := 0
+		for _, table := range candidates {
+			if _, ok := selected[table]; ok {
+				continue
+			}
+			count := 0
+			for _, address := range usable[table] {
+				if uncovered[address] {
+					count++
+				}
+			}
+			if count > bestCount {
+				best, bestCount = table, count
+			}
+		}
+		if bestCount < 2 {
+			break
+		}
+
+		selected[best] = usable[best]
+		for _, address := range usable[best] {
+			delete(uncovered, address)
+		}
+	}
+
+	return selected
+}
+
+// Create returns a new table owned by authority and the instructions filling it with addresses, grouped so
+// each group fits one transaction. The first group creates the table. Once they have landed, Load the table.
+// Tables can be used from the slot after their last extension.
+func (c *LookupTableCache) Create(ctx context.Context, authority solana.PublicKey, payer solana.PublicKey, addresses solana.PublicKeySlice) (solana.PublicKey, [][]solana.Instruction, error) {
+	if len(addresses) > addresslookuptable.LOOKUP_TABLE_MAX_ADDRESSES {
+		return solana.PublicKey{}, nil, fmt.Errorf("%d addresses exceed the lookup table limit of %d", len(addresses), addresslookuptable.LOOKUP_TABLE_MAX_ADDRESSES)
+	}
+
+	slot, err := c.client.GetSlot(ctx, c.Commitment)
+	if err != nil {
+		return solana.PublicKey{}, nil, fmt.Errorf("failed to get slot: %w", err)
+	}
+
+	createIx, table, err := instructions.CreateLookupTable(authority, payer, slot)
+	if err != nil {
+		return solana.PublicKey{}, nil, fmt.Errorf("failed to derive lookup table address: %w", err)
+	}
+
+	var unique solana.PublicKeySlice
+	for _, address := range addresses {
+		unique.UniqueAppend(address)
+	}
+
+	groups := extendInstructions(table, authority, payer, unique)
+	if len(groups) == 0 {
+		groups = [][]solana.Instruction{{}}
+	}
+	groups[0] = append([]solana.Instruction{createIx}, groups[0]...)
+
+	return table, groups, nil
+}
+
+// Extend returns the instructions appending the addresses missing from a cached table, one group per
+// transaction. Once they have landed, Load the table again.
+func (c *LookupTableCache) Extend(table solana.PublicKey, authority solana.PublicKey, payer solana.PublicKey, addresses solana.PublicKeySlice) ([][]solana.Instruction, error) {
+	c.mu.RLock()
+	cached, ok := c.tables[table]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("lookup table %s is not cached", table)
+	}
+	existing := cached.addresses
+
+	var missing solana.PublicKeySlice
+	for _, address := range addresses {
+		if !existing.Has(address) && !missing.Has(address) {
+			missing = append(missing, address)
+		}
+	}
+	if len(existing)+len(missing) > addresslookuptable.LOOKUP_TABLE_MAX_ADDRESSES {
+		return nil, fmt.Errorf("lookup table %s would hold %d addresses, the limit is %d", table, len(existing)+len(missing), addresslookuptable.LOOKUP_TABLE_MAX_ADDRESSES)
+	}
+
+	return extendInstructions(table, authority, payer, missing), nil
+}
+
+// extendInstructions splits addresses into ExtendLookupTable instructions of maxExtendAddresses, one per group
+func extendInstructions(table solana.PublicKey, authority solana.PublicKey, payer solana.PublicKey, addresses solana.PublicKeySlice) [][]solana.Instruction {
+	var groups [][]solana.Instruction
+	for start := 0; start < len(addresses); start += maxExtendAddresses {
+		end := start + maxExtendAddresses
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+		groups = append(groups, []solana.Instruction{
+			instructions.ExtendLookupTable(table, authority, payer, addresses[start:end]),
+		})
+	}
+	return groups
+}
@@ -0,0 +1,84 @@
+package transaction
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// fakeLookupTableClient serves lookup table accounts
+type fakeLookupTableClient struct {
+	accounts map[solana.PublicKey]*rpc.Account
+}
+
+func (c *fakeLookupTableClient) GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey, opts *rpc.GetMultipleAccountsOpts) (*rpc.GetMultipleAccountsResult, error) {
+	result := &rpc.GetMultipleAccountsResult{Value: make([]*rpc.Account, len(accounts))}
+	for i, account := range accounts {
+		result.Value[i] = c.accounts[account]
+	}
+	return result, nil
+}
+
+func (c *fakeLookupTableClient) GetSlot(ctx context.Context, commitment rpc.CommitmentType) (uint64, error) {
+	return 0, nil
+}
+
+// lookupTableAccount encodes an active table whose addresses from startIndex on were appended in lastExtendedSlot
+func lookupTableAccount(addresses solana.PublicKeySlice, lastExtendedSlot uint64, startIndex uint8) *rpc.Account {
+	data := make([]byte, 56, 56+32*len(addresses))
+	binary.LittleEndian.PutUint32(data[0:4], 1)
+	binary.LittleEndian.PutUint64(data[4:12], math.MaxUint64)
+	binary.LittleEndian.PutUint64(data[12:20], lastExtendedSlot)
+	data[20] = startIndex
+	for _, address := range addresses {
+		data = append(data, address.Bytes()...)
+	}
+
+	return &rpc.Account{
+		Owner: solana.MustPublicKeyFromBase58(common.AddressLookupTableProgram),
+		Data:  rpc.DataBytesOrJSONFromBytes(data),
+	}
+}
+
+func TestLookupTableSelectSkipsAddressesExtendedInTheCurrentSlot(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	addresses := make(solana.PublicKeySlice, 4)
+	for i := range addresses {
+		addresses[i] = solana.NewWallet().PublicKey()
+	}
+
+	table := solana.NewWallet().PublicKey()
+	client := &fakeLookupTableClient{accounts: map[solana.PublicKey]*rpc.Account{
+		// The last two addresses were appended in slot 100
+		table: lookupTableAccount(addresses, 100, 2),
+	}}
+	cache := NewLookupTableCache(client)
+	if err := cache.Load(context.Background(), table); err != nil {
+		t.Fatalf("failed to load lookup table: %v", err)
+	}
+
+	metas := make(solana.AccountMetaSlice, len(addresses))
+	for i, address := range addresses {
+		metas[i] = &solana.AccountMeta{PublicKey: address, IsWritable: true}
+	}
+	instructions := []solana.Instruction{solana.NewInstruction(solana.SystemProgramID, metas, nil)}
+
+	selected := cache.Select(payer, instructions, 100)
+	if got := selected[table]; len(got) != 2 || !got[0].Equals(addresses[0]) || !got[1].Equals(addresses[1]) {
+		t.Fatalf("expected only the addresses usable at slot 100, got %v", got)
+	}
+
+	selected = cache.Select(payer, instructions, 101)
+	if got := selected[table]; len(got) != 4 {
+		t.Fatalf("expected every address to be usable at slot 101, got %v", got)
+	}
+
+	if tables := cache.Tables(); len(tables[table]) != 4 {
+		t.Fatalf("expected the cache to keep every address, got %v", tables[table])
+	}
+}
@@ -0,0 +1,284 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// maxSignatureStatuses is the most signatures getSignatureStatuses accepts
+const maxSignatureStatuses = 256
+
+// pollTimeout bounds the requests of one polling round
+const pollTimeout = 10 * time.Second
+
+const (
+	// DefaultPollInterval is used when Sender.PollInterval is not positive
+	DefaultPollInterval = time.Second
+	// DefaultRebroadcastInterval is the rebroadcast interval of NewSender
+	DefaultRebroadcastInterval = 2 * time.Second
+)
+
+// SenderClient is the subset of RPC methods transactions are sent and confirmed with.
+// *rpc.Client satisfies it, and tests can substitute a fake.
+type SenderClient interface {
+	SendTransactionWithOpts(ctx context.Context, transaction *solana.Transaction, opts rpc.TransactionOpts) (solana.Signature, error)
+	GetSignatureStatuses(ctx context.Context, searchTransactionHistory bool, transactionSignatures ...solana.Signature) (*rpc.GetSignatureStatusesResult, error)
+	GetBlockHeight(ctx context.Context, commitment rpc.CommitmentType) (uint64, error)
+}
+
+var _ SenderClient = (*rpc.Client)(nil)
+
+// OutcomeStatus is how a sent transaction ended
+type OutcomeStatus uint8
+
+const (
+	// OutcomeLanded means the transaction succeeded at the sender's commitment
+	OutcomeLanded OutcomeStatus = iota
+	// OutcomeFailed means the transaction landed at the sender's commitment with an error
+	OutcomeFailed
+	// OutcomeExpired means the blockhash expired before the transaction landed, it can be rebuilt and sent again
+	OutcomeExpired
+)
+
+func (s OutcomeStatus) String() string {
+	switch s {
+	case OutcomeLanded:
+		return "landed"
+	case OutcomeFailed:
+		return "failed"
+	case OutcomeExpired:
+		return "expired"
+	default:
+		return fmt.Sprintf("OutcomeStatus(%d)", uint8(s))
+	}
+}
+
+// Outcome is the final state of a sent transaction
+type Outcome struct {
+	Signature solana.Signature
+	Status    OutcomeStatus
+	// Slot the transaction landed in, 0 if it expired
+	Slot uint64
+	// Err is the decoded error of a failed transaction. getSignatureStatuses returns no logs, so Err.Logs is empty.
+	Err *TransactionError
+	// Sends counts the first send and every rebroadcast
+	Sends int
+}
+
+// pendingTransaction is a transaction sent but not yet landed or expired
+type pendingTransaction struct {
+	tx       *Transaction
+	lastSent time.Time
+	sends    int
+	done     chan *Outcome
+}
+
+// Sender broadcasts signed transactions until they land or their blockhash expires.
+// Transactions sent concurrently are confirmed together with batched getSignatureStatuses.
+type Sender struct {
+	client SenderClient
+
+	// Commitment a transaction must reach to land, and at which block height is read
+	Commitment rpc.CommitmentType
+	// ProgramID is the cp_amm program whose error codes are decoded
+	ProgramID solana.PublicKey
+	// PollInterval is the time between two rounds of status checks, DefaultPollInterval if not positive
+	PollInterval time.Duration
+	// RebroadcastInterval is the time between two sends of a transaction that has not been seen yet
+	RebroadcastInterval time.Duration
+	// SkipPreflight skips the simulation of the first send, rebroadcasts always skip it
+	SkipPreflight bool
+
+	mu      sync.Mutex
+	pending map[solana.Signature]*pendingTransaction
+	running bool
+}
+
+// NewSender creates a sender confirming at the confirmed commitment
+func NewSender(client SenderClient) *Sender {
+	return &Sender{
+		client:              client,
+		Commitment:          rpc.CommitmentConfirmed,
+		ProgramID:           solana.MustPublicKeyFromBase58(common.DammV2ProgramID),
+		PollInterval:        DefaultPollInterval,
+		RebroadcastInterval: DefaultRebroadcastInterval,
+		pending:             make(map[solana.Signature]*pendingTransaction),
+	}
+}
+
+// Send broadcasts a signed transaction and waits until it lands, fails or its blockhash expires.
+// An error is returned only if the first send is rejected or ctx is done.
+func (s *Sender) Send(ctx context.Context, tx *Transaction) (*Outcome, error) {
+	if len(tx.Transaction.Signatures) == 0 {
+		return nil, fmt.Errorf("transaction is not signed")
+	}
+	signature := tx.Transaction.Signatures[0]
+
+	pending := &pendingTransaction{
+		tx:       tx,
+		lastSent: time.Now(),
+		sends:    1,
+		done:     make(chan *Outcome, 1),
+	}
+
+	s.mu.Lock()
+	if s.pending == nil {
+		s.pending = make(map[solana.Signature]*pendingTransaction)
+	}
+	if _, tracked := s.pending[signature]; tracked {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("transaction %s is already being sent", signature)
+	}
+	s.pending[signature] = pending
+	s.mu.Unlock()
+
+	if err := s.broadcast(ctx, tx.Transaction, s.SkipPreflight); err != nil {
+		s.mu.Lock()
+		delete(s.pending, signature)
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to send transaction: %w", err)
+	}
+
+	s.mu.Lock()
+	if !s.running {
+		s.running = true
+		go s.run()
+	}
+	s.mu.Unlock()
+
+	select {
+	case outcome := <-pending.done:
+		return outcome, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		delete(s.pending, signature)
+		s.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// broadcast sends tx once, leaving retries to the sender instead of the RPC node
+func (s *Sender) broadcast(ctx context.Context, tx *solana.Transaction, skipPreflight bool) error {
+	maxRetries := uint(0)
+	_, err := s.client.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{
+		SkipPreflight:       skipPreflight,
+		PreflightCommitment: s.Commitment,
+		MaxRetries:          &maxRetries,
+	})
+	return err
+}
+
+// run polls until no transaction is pending
+func (s *Sender) run() {
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		if len(s.pending) == 0 {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		pending := make(map[solana.Signature]*pendingTransaction, len(s.pending))
+		for signature, p := range s.pending {
+			pending[signature] = p
+		}
+		s.mu.Unlock()
+
+		s.poll(pending)
+	}
+}
+
+// poll checks the status of every pending transaction, resolving those that landed or expired and
+// rebroadcasting the others
+func (s *Sender) poll(pending map[solana.Signature]*pendingTransaction) {
+	ctx, cancel := context.WithTimeout(context.Background(), pollTimeout)
+	defer cancel()
+
+	// Block height is read before the statuses, so a transaction missing from them cannot land later
+	// once the height is past its last valid block height
+	blockHeight, heightErr := s.client.GetBlockHeight(ctx, s.Commitment)
+
+	signatures := make([]solana.Signature, 0, len(pending))
+	for signature := range pending {
+		signatures = append(signatures, signature)
+	}
+
+	statuses := make(map[solana.Signature]*rpc.SignatureStatusesResult, len(signatures))
+	for start := 0; start < len(signatures); start += maxSignatureStatuses {
+		end := start + maxSignatureStatuses
+		if end > len(signatures) {
+			end = len(signatures)
+		}
+
+		result, err := s.client.GetSignatureStatuses(ctx, false, signatures[start:end]...)
+		if err != nil {
+			// Retried on the next round
+			return
+		}
+		for i, status := range result.Value {
+			if i < end-start {
+				statuses[signatures[start+i]] = status
+			}
+		}
+	}
+
+	for signature, p := range pending {
+		status := statuses[signature]
+		switch {
+		case status != nil && reachedCommitment(status.ConfirmationStatus, s.Commitment):
+			outcome := &Outcome{Signature: signature, Status: OutcomeLanded, Slot: status.Slot, Sends: p.sends}
+			if status.Err != nil {
+				outcome.Status = OutcomeFailed
+				outcome.Err = DecodeTransactionError(p.tx.Transaction, status.Err, nil, s.ProgramID)
+			}
+			s.resolve(signature, outcome)
+		case status != nil:
+			// Processed but not yet at the commitment, rebroadcasting would not help
+		case heightErr == nil && blockHeight > p.tx.LastValidBlockHeight:
+			s.resolve(signature, &Outcome{Signature: signature, Status: OutcomeExpired, Sends: p.sends})
+		case time.Since(p.lastSent) >= s.RebroadcastInterval:
+			if err := s.broadcast(ctx, p.tx.Transaction, true); err == nil {
+				p.sends++
+			}
+			p.lastSent = time.Now()
+		}
+	}
+}
+
+// resolve hands outcome to the caller waiting on signature, unless it stopped waiting
+func (s *Sender) resolve(signature solana.Signature, outcome *Outcome) {
+	s.mu.Lock()
+	p, ok := s.pending[signature]
+	delete(s.pending, signature)
+	s.mu.Unlock()
+
+	if ok {
+		p.done <- outcome
+	}
+}
+
+// reachedCommitment reports whether a confirmation status is at least commitment
+func reachedCommitment(status rpc.ConfirmationStatusType, commitment rpc.CommitmentType) bool {
+	rank := map[string]int{
+		string(rpc.ConfirmationStatusProcessed): 0,
+		string(rpc.ConfirmationStatusConfirmed): 1,
+		string(rpc.ConfirmationStatusFinalized): 2,
+	}
+	statusRank, ok := rank[string(status)]
+	if !ok {
+		return false
+	}
+	return statusRank >= rank[string(commitment)]
+}
@@ -0,0 +1,218 @@
+package transaction
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// fakeSenderClient lands a transaction once it was sent landAfter times, with landErr as its status error
+type fakeSenderClient struct {
+	mu          sync.Mutex
+	landAfter   int
+	landErr     interface{}
+	blockHeight uint64
+	sends       map[solana.Signature]int
+	// statusBatches records the size of every getSignatureStatuses request
+	statusBatches []int
+}
+
+func newFakeSenderClient(landAfter int) *fakeSenderClient {
+	return &fakeSenderClient{landAfter: landAfter, sends: make(map[solana.Signature]int)}
+}
+
+func (c *fakeSenderClient) SendTransactionWithOpts(ctx context.Context, transaction *solana.Transaction, opts rpc.TransactionOpts) (solana.Signature, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sends[transaction.Signatures[0]]++
+	return transaction.Signatures[0], nil
+}
+
+func (c *fakeSenderClient) GetSignatureStatuses(ctx context.Context, searchTransactionHistory bool, transactionSignatures ...solana.Signature) (*rpc.GetSignatureStatusesResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statusBatches = append(c.statusBatches, len(transactionSignatures))
+
+	result := &rpc.GetSignatureStatusesResult{Value: make([]*rpc.SignatureStatusesResult, len(transactionSignatures))}
+	for i, signature := range transactionSignatures {
+		if c.landAfter > 0 && c.sends[signature] >= c.landAfter {
+			result.Value[i] = &rpc.SignatureStatusesResult{
+				Slot:               100,
+				Err:                c.landErr,
+				ConfirmationStatus: rpc.ConfirmationStatusConfirmed,
+			}
+		}
+	}
+	return result, nil
+}
+
+func (c *fakeSenderClient) GetBlockHeight(ctx context.Context, commitment rpc.CommitmentType) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.blockHeight, nil
+}
+
+func (c *fakeSenderClient) Sends(signature solana.Signature) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sends[signature]
+}
+
+// signedTransaction returns a transaction calling the cp_amm program, made unique by nonce
+func signedTransaction(t *testing.T, wallet *solana.Wallet, nonce uint64) *Transaction {
+	t.Helper()
+
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, nonce)
+	instruction := solana.NewInstruction(
+		solana.MustPublicKeyFromBase58(common.DammV2ProgramID),
+		solana.AccountMetaSlice{{PublicKey: wallet.PublicKey(), IsSigner: true, IsWritable: true}},
+		data,
+	)
+
+	tx, err := solana.NewTransaction([]solana.Instruction{instruction}, solana.Hash{1}, solana.TransactionPayer(wallet.PublicKey()))
+	if err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+	if _, err := tx.Sign(func(solana.PublicKey) *solana.PrivateKey { return &wallet.PrivateKey }); err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return &Transaction{Transaction: tx, LastValidBlockHeight: 1000}
+}
+
+func newTestSender(client SenderClient) *Sender {
+	sender := NewSender(client)
+	sender.PollInterval = 5 * time.Millisecond
+	sender.RebroadcastInterval = 20 * time.Millisecond
+	return sender
+}
+
+func send(t *testing.T, sender *Sender, tx *Transaction) *Outcome {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	outcome, err := sender.Send(ctx, tx)
+	if err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	return outcome
+}
+
+func TestSenderLanded(t *testing.T) {
+	client := newFakeSenderClient(1)
+	tx := signedTransaction(t, solana.NewWallet(), 0)
+
+	outcome := send(t, newTestSender(client), tx)
+	if outcome.Status != OutcomeLanded || outcome.Slot != 100 || outcome.Err != nil {
+		t.Fatalf("expected the transaction to land at slot 100, got %+v", outcome)
+	}
+	if outcome.Signature != tx.Transaction.Signatures[0] || outcome.Sends != 1 {
+		t.Fatalf("expected one send of the transaction, got %+v", outcome)
+	}
+}
+
+func TestSenderFailed(t *testing.T) {
+	client := newFakeSenderClient(1)
+	client.landErr = map[string]interface{}{
+		"InstructionError": []interface{}{float64(0), map[string]interface{}{"Custom": float64(6002)}},
+	}
+
+	outcome := send(t, newTestSender(client), signedTransaction(t, solana.NewWallet(), 0))
+	if outcome.Status != OutcomeFailed || outcome.Err == nil {
+		t.Fatalf("expected the transaction to fail, got %+v", outcome)
+	}
+	if outcome.Err.Code == nil || *outcome.Err.Code != 6002 || outcome.Err.Name != "ExceededSlippage" {
+		t.Fatalf("expected ExceededSlippage, got %v", outcome.Err)
+	}
+}
+
+func TestSenderExpired(t *testing.T) {
+	client := newFakeSenderClient(0)
+	client.blockHeight = 1001
+
+	outcome := send(t, newTestSender(client), signedTransaction(t, solana.NewWallet(), 0))
+	if outcome.Status != OutcomeExpired || outcome.Slot != 0 {
+		t.Fatalf("expected the blockhash to expire, got %+v", outcome)
+	}
+}
+
+func TestSenderRebroadcastsUntilLanded(t *testing.T) {
+	client := newFakeSenderClient(3)
+	tx := signedTransaction(t, solana.NewWallet(), 0)
+
+	outcome := send(t, newTestSender(client), tx)
+	if outcome.Status != OutcomeLanded || outcome.Sends != 3 {
+		t.Fatalf("expected the transaction to land after 3 sends, got %+v", outcome)
+	}
+	if sends := client.Sends(tx.Transaction.Signatures[0]); sends != 3 {
+		t.Fatalf("expected 3 sends to reach the node, got %d", sends)
+	}
+}
+
+func TestSenderBatchesConcurrentTransactions(t *testing.T) {
+	client := newFakeSenderClient(2)
+	sender := newTestSender(client)
+	wallet := solana.NewWallet()
+
+	const count = maxSignatureStatuses + 44
+	txs := make([]*Transaction, count)
+	for i := range txs {
+		txs[i] = signedTransaction(t, wallet, uint64(i))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	outcomes := make([]*Outcome, count)
+	errs := make([]error, count)
+	var wg sync.WaitGroup
+	for i, tx := range txs {
+		wg.Add(1)
+		go func(i int, tx *Transaction) {
+			defer wg.Done()
+			outcomes[i], errs[i] = sender.Send(ctx, tx)
+		}(i, tx)
+	}
+	wg.Wait()
+
+	for i, outcome := range outcomes {
+		if errs[i] != nil {
+			t.Fatalf("failed to send transaction %d: %v", i, errs[i])
+		}
+		if outcome.Status != OutcomeLanded || outcome.Signature != txs[i].Transaction.Signatures[0] {
+			t.Fatalf("expected transaction %d to land, got %+v", i, outcome)
+		}
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	largest := 0
+	for _, size := range client.statusBatches {
+		if size > maxSignatureStatuses {
+			t.Fatalf("expected at most %d signatures per request, got %d", maxSignatureStatuses, size)
+		}
+		if size > largest {
+			largest = size
+		}
+	}
+	if largest != maxSignatureStatuses {
+		t.Fatalf("expected pending transactions to be checked together, largest batch was %d", largest)
+	}
+}
+
+func TestSenderZeroValue(t *testing.T) {
+	sender := &Sender{client: newFakeSenderClient(1)}
+
+	outcome := send(t, sender, signedTransaction(t, solana.NewWallet(), 0))
+	if outcome.Status != OutcomeLanded {
+		t.Fatalf("expected the transaction to land, got %+v", outcome)
+	}
+}
@@ -0,0 +1,210 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/dannwee/dbc-go/common"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// BalanceChange is how a token account's balance would change if the transaction landed
+type BalanceChange struct {
+	Account solana.PublicKey
+	Owner   solana.PublicKey
+	Mint    solana.PublicKey
+	Pre     uint64
+	Post    uint64
+	// Delta is Post - Pre
+	Delta *big.Int
+}
+
+// Simulation is the outcome of a successful simulateTransaction
+type Simulation struct {
+	// UnitsConsumed is zero if the node did not report it
+	UnitsConsumed uint64
+	Logs          []string
+	// Slot is the context slot the transaction was simulated at
+	Slot uint64
+	// BalanceSlot is the context slot the pre balances were read at, after Slot if the node moved on
+	BalanceSlot uint64
+	// BalanceChanges lists the writable token accounts whose balance changes, in account order
+	BalanceChanges []BalanceChange
+}
+
+// BuildSimulated builds a transaction with the maximum compute unit limit, simulates it and rebuilds it
+// with the consumed units plus ComputeUnitMargin. If the node does not report the consumed units, the
+// transaction keeps MaxComputeUnitLimit. A failed simulation returns a *TransactionError.
+func (b *Builder) BuildSimulated(ctx context.Context, instructions ...solana.Instruction) (*Transaction, *Simulation, error) {
+	tx, err := b.build(ctx, instructions, MaxComputeUnitLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	simulation, err := b.Simulate(ctx, tx.Transaction)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Keep the limit the transaction was simulated with if the node did not report the consumed units
+	if simulation.UnitsConsumed == 0 {
+		return tx, simulation, nil
+	}
+
+	limit := simulation.UnitsConsumed + uint64(math.Ceil(float64(simulation.UnitsConsumed)*b.ComputeUnitMargin))
+	if limit > uint64(MaxComputeUnitLimit) {
+		limit = uint64(MaxComputeUnitLimit)
+	}
+
+	tx.ComputeUnitLimit = uint32(limit)
+	tx.Transaction, err = b.assemble(instructions, tx.Transaction.Message.RecentBlockhash, simulation.Slot, tx.ComputeUnitLimit, tx.ComputeUnitPrice)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tx, simulation, nil
+}
+
+// Simulate runs simulateTransaction without verifying signatures, so tx may be unsigned.
+// Balance changes compare the simulated state of the writable token accounts with their state read
+// at the simulation's slot. simulateTransaction cannot be pinned to a slot, so the accounts are read
+// after it with the simulation's slot as the minimum context slot. If the node has moved past that slot
+// by then, the pre balances come from a later slot and can include the effect of other transactions.
+// A failed simulation returns a *TransactionError.
+func (b *Builder) Simulate(ctx context.Context, tx *solana.Transaction) (*Simulation, error) {
+	accounts, err := writableUnsignedAccounts(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	commitment := b.SimulationCommitment
+	if commitment == "" {
+		commitment = rpc.CommitmentConfirmed
+	}
+
+	// simulateTransaction rejects a transaction without its signature slots, even when not verifying them
+	if len(tx.Signatures) == 0 {
+		unsigned := *tx
+		unsigned.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+		tx = &unsigned
+	}
+
+	response, err := b.client.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		Commitment: commitment,
+		Accounts: &rpc.SimulateTransactionAccountsOpts{
+			Encoding:  solana.EncodingBase64,
+			Addresses: accounts,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate transaction: %w", err)
+	}
+	if response == nil || response.Value == nil {
+		return nil, fmt.Errorf("failed to simulate transaction: empty response")
+	}
+
+	result := response.Value
+	if result.Err != nil {
+		return nil, DecodeTransactionError(tx, result.Err, result.Logs, b.ProgramID)
+	}
+
+	simulation := &Simulation{Logs: result.Logs, Slot: response.Context.Slot}
+	if result.UnitsConsumed != nil {
+		simulation.UnitsConsumed = *result.UnitsConsumed
+	}
+
+	var pre []*rpc.Account
+	if len(accounts) > 0 {
+		slot := response.Context.Slot
+		preResult, err := b.client.GetMultipleAccountsWithOpts(ctx, accounts, &rpc.GetMultipleAccountsOpts{
+			Commitment:     commitment,
+			Encoding:       solana.EncodingBase64,
+			MinContextSlot: &slot,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get accounts at the simulation slot: %w", err)
+		}
+		pre = preResult.Value
+		simulation.BalanceSlot = preResult.Context.Slot
+	}
+
+	for i, account := range accounts {
+		var before, after *rpc.Account
+		if i < len(pre) {
+			before = pre[i]
+		}
+		if i < len(result.Accounts) {
+			after = result.Accounts[i]
+		}
+
+		change, err := balanceChange(account, before, after)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil && change.Delta.Sign() != 0 {
+			simulation.BalanceChanges = append(simulation.BalanceChanges, *change)
+		}
+	}
+
+	return simulation, nil
+}
+
+// balanceChange compares two states of an account, returning nil if neither is a token account.
+// A token account created or closed by the transaction counts as a zero balance on the missing side.
+func balanceChange(address solana.PublicKey, before *rpc.Account, after *rpc.Account) (*BalanceChange, error) {
+	pre, err := decodeTokenBalance(before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token account %s: %w", address, err)
+	}
+	post, err := decodeTokenBalance(after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode simulated token account %s: %w", address, err)
+	}
+	if pre == nil && post == nil {
+		return nil, nil
+	}
+
+	change := &BalanceChange{Account: address}
+	for _, state := range []*common.TokenAccountLayout{pre, post} {
+		if state != nil {
+			change.Owner = state.Owner
+			change.Mint = state.Mint
+		}
+	}
+	if pre != nil {
+		change.Pre = pre.Amount
+	}
+	if post != nil {
+		change.Post = post.Amount
+	}
+	change.Delta = new(big.Int).Sub(new(big.Int).SetUint64(change.Post), new(big.Int).SetUint64(change.Pre))
+
+	return change, nil
+}
+
+// decodeTokenBalance decodes account if it is a token account, returning nil otherwise
+func decodeTokenBalance(account *rpc.Account) (*common.TokenAccountLayout, error) {
+	if account == nil || account.Data == nil || common.ValidateTokenAccount(account) != nil {
+		return nil, nil
+	}
+	return common.DecodeTokenAccount(account.Data.GetBinary())
+}
+
+// writableUnsignedAccounts returns the accounts tx writes to, excluding signers
+func writableUnsignedAccounts(tx *solana.Transaction) ([]solana.PublicKey, error) {
+	writable, err := tx.Message.Writable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list writable accounts: %w", err)
+	}
+
+	accounts := make([]solana.PublicKey, 0, len(writable))
+	for _, account := range writable {
+		if !tx.Message.IsSigner(account) {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
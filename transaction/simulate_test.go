@@ -0,0 +1,263 @@
+package transaction
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// fakeRPCClient serves a fixed blockhash, prioritization fees and simulation result
+type fakeRPCClient struct {
+	fees []uint64
+	// feeAccounts records the accounts of the last getRecentPrioritizationFees request
+	feeAccounts   solana.PublicKeySlice
+	unitsConsumed *uint64
+	simulateErr   interface{}
+	logs          []string
+	// simulated and accounts hold the account states after and before the transaction
+	simulated map[solana.PublicKey]*rpc.Account
+	accounts  map[solana.PublicKey]*rpc.Account
+}
+
+func (c *fakeRPCClient) GetLatestBlockhash(ctx context.Context, commitment rpc.CommitmentType) (*rpc.GetLatestBlockhashResult, error) {
+	return &rpc.GetLatestBlockhashResult{
+		RPCContext: rpc.RPCContext{Context: rpc.Context{Slot: 100}},
+		Value:      &rpc.LatestBlockhashResult{Blockhash: solana.Hash{1}, LastValidBlockHeight: 1000},
+	}, nil
+}
+
+func (c *fakeRPCClient) GetRecentPrioritizationFees(ctx context.Context, accounts solana.PublicKeySlice) ([]rpc.PriorizationFeeResult, error) {
+	c.feeAccounts = accounts
+	fees := make([]rpc.PriorizationFeeResult, len(c.fees))
+	for i, fee := range c.fees {
+		fees[i] = rpc.PriorizationFeeResult{Slot: uint64(i), PrioritizationFee: fee}
+	}
+	return fees, nil
+}
+
+func (c *fakeRPCClient) SimulateTransactionWithOpts(ctx context.Context, transaction *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResponse, error) {
+	result := &rpc.SimulateTransactionResult{Err: c.simulateErr, Logs: c.logs, UnitsConsumed: c.unitsConsumed}
+	for _, address := range opts.Accounts.Addresses {
+		result.Accounts = append(result.Accounts, c.simulated[address])
+	}
+	return &rpc.SimulateTransactionResponse{
+		RPCContext: rpc.RPCContext{Context: rpc.Context{Slot: 101}},
+		Value:      result,
+	}, nil
+}
+
+func (c *fakeRPCClient) GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey, opts *rpc.GetMultipleAccountsOpts) (*rpc.GetMultipleAccountsResult, error) {
+	result := &rpc.GetMultipleAccountsResult{
+		RPCContext: rpc.RPCContext{Context: rpc.Context{Slot: *opts.MinContextSlot}},
+		Value:      make([]*rpc.Account, len(accounts)),
+	}
+	for i, account := range accounts {
+		result.Value[i] = c.accounts[account]
+	}
+	return result, nil
+}
+
+// tokenAccount encodes an SPL token account holding amount of mint
+func tokenAccount(mint solana.PublicKey, owner solana.PublicKey, amount uint64) *rpc.Account {
+	data := make([]byte, 165)
+	copy(data[0:32], mint.Bytes())
+	copy(data[32:64], owner.Bytes())
+	binary.LittleEndian.PutUint64(data[64:72], amount)
+	data[108] = 1
+
+	return &rpc.Account{Owner: solana.TokenProgramID, Data: rpc.DataBytesOrJSONFromBytes(data)}
+}
+
+// testInstruction writes to the given accounts, signed by payer
+func testInstruction(payer solana.PublicKey, writable ...solana.PublicKey) solana.Instruction {
+	metas := solana.AccountMetaSlice{{PublicKey: payer, IsSigner: true, IsWritable: true}}
+	for _, account := range writable {
+		metas = append(metas, &solana.AccountMeta{PublicKey: account, IsWritable: true})
+	}
+	return solana.NewInstruction(solana.SystemProgramID, metas, nil)
+}
+
+// computeUnitLimit decodes the SetComputeUnitLimit instruction at the start of tx
+func computeUnitLimit(t *testing.T, tx *solana.Transaction) uint32 {
+	t.Helper()
+
+	data := tx.Message.Instructions[0].Data
+	if len(data) != 5 || data[0] != 2 {
+		t.Fatalf("expected SetComputeUnitLimit first, got %v", data)
+	}
+	return binary.LittleEndian.Uint32(data[1:])
+}
+
+func TestBuildSimulatedSizesComputeUnitLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		unitsConsumed *uint64
+		want          uint32
+	}{
+		{name: "consumed plus margin", unitsConsumed: func() *uint64 { units := uint64(50_000); return &units }(), want: 55_000},
+		{name: "consumed units missing", unitsConsumed: nil, want: MaxComputeUnitLimit},
+		{name: "zero consumed units", unitsConsumed: new(uint64), want: MaxComputeUnitLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payer := solana.NewWallet().PublicKey()
+			builder := NewBuilder(&fakeRPCClient{unitsConsumed: tt.unitsConsumed}, payer)
+
+			tx, _, err := builder.BuildSimulated(context.Background(), testInstruction(payer, solana.NewWallet().PublicKey()))
+			if err != nil {
+				t.Fatalf("failed to build simulated transaction: %v", err)
+			}
+			if tx.ComputeUnitLimit != tt.want {
+				t.Fatalf("expected a limit of %d, got %d", tt.want, tx.ComputeUnitLimit)
+			}
+			if limit := computeUnitLimit(t, tx.Transaction); limit != tt.want {
+				t.Fatalf("expected the message to request %d units, got %d", tt.want, limit)
+			}
+		})
+	}
+}
+
+func TestBalanceChange(t *testing.T) {
+	address := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+	owner := solana.NewWallet().PublicKey()
+	system := &rpc.Account{Owner: solana.SystemProgramID, Data: rpc.DataBytesOrJSONFromBytes(nil)}
+
+	tests := []struct {
+		name   string
+		before *rpc.Account
+		after  *rpc.Account
+		// nil if no change is expected
+		want *BalanceChange
+	}{
+		{
+			name:   "transfer in",
+			before: tokenAccount(mint, owner, 100),
+			after:  tokenAccount(mint, owner, 250),
+			want:   &BalanceChange{Pre: 100, Post: 250},
+		},
+		{
+			name:   "created by the transaction",
+			before: nil,
+			after:  tokenAccount(mint, owner, 40),
+			want:   &BalanceChange{Pre: 0, Post: 40},
+		},
+		{
+			name:   "closed by the transaction",
+			before: tokenAccount(mint, owner, 40),
+			after:  nil,
+			want:   &BalanceChange{Pre: 40, Post: 0},
+		},
+		{
+			name:   "closed and handed back to the system program",
+			before: tokenAccount(mint, owner, 40),
+			after:  system,
+			want:   &BalanceChange{Pre: 40, Post: 0},
+		},
+		{
+			name:   "not a token account",
+			before: system,
+			after:  system,
+		},
+		{
+			name: "missing on both sides",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, err := balanceChange(address, tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("failed to compute balance change: %v", err)
+			}
+			if tt.want == nil {
+				if change != nil {
+					t.Fatalf("expected no change, got %+v", change)
+				}
+				return
+			}
+
+			if change == nil {
+				t.Fatal("expected a change")
+			}
+			if !change.Account.Equals(address) || !change.Mint.Equals(mint) || !change.Owner.Equals(owner) {
+				t.Fatalf("unexpected accounts %+v", change)
+			}
+			delta := int64(tt.want.Post) - int64(tt.want.Pre)
+			if change.Pre != tt.want.Pre || change.Post != tt.want.Post || change.Delta.Int64() != delta {
+				t.Fatalf("expected %d -> %d (%d), got %d -> %d (%s)", tt.want.Pre, tt.want.Post, delta, change.Pre, change.Post, change.Delta)
+			}
+		})
+	}
+}
+
+func TestSimulateReportsBalanceChanges(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+	vault := solana.NewWallet().PublicKey()
+	created := solana.NewWallet().PublicKey()
+	unchanged := solana.NewWallet().PublicKey()
+
+	units := uint64(30_000)
+	client := &fakeRPCClient{
+		unitsConsumed: &units,
+		accounts: map[solana.PublicKey]*rpc.Account{
+			vault:     tokenAccount(mint, vault, 1_000),
+			unchanged: tokenAccount(mint, payer, 5),
+		},
+		simulated: map[solana.PublicKey]*rpc.Account{
+			vault:     tokenAccount(mint, vault, 900),
+			created:   tokenAccount(mint, payer, 100),
+			unchanged: tokenAccount(mint, payer, 5),
+		},
+	}
+	builder := NewBuilder(client, payer)
+
+	tx, err := builder.Build(context.Background(), testInstruction(payer, vault, created, unchanged))
+	if err != nil {
+		t.Fatalf("failed to build transaction: %v", err)
+	}
+	simulation, err := builder.Simulate(context.Background(), tx.Transaction)
+	if err != nil {
+		t.Fatalf("failed to simulate transaction: %v", err)
+	}
+
+	if simulation.UnitsConsumed != units || simulation.Slot != 101 || simulation.BalanceSlot != 101 {
+		t.Fatalf("unexpected simulation %+v", simulation)
+	}
+	deltas := make(map[solana.PublicKey]int64)
+	for _, change := range simulation.BalanceChanges {
+		deltas[change.Account] = change.Delta.Int64()
+	}
+	if len(deltas) != 2 || deltas[vault] != -100 || deltas[created] != 100 {
+		t.Fatalf("expected the vault to lose 100 and the new account to gain 100, got %v", deltas)
+	}
+}
+
+func TestSimulateDecodesFailure(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	client := &fakeRPCClient{
+		simulateErr: map[string]interface{}{
+			"InstructionError": []interface{}{float64(0), "InvalidAccountData"},
+		},
+	}
+	builder := NewBuilder(client, payer)
+
+	tx, err := builder.Build(context.Background(), testInstruction(payer))
+	if err != nil {
+		t.Fatalf("failed to build transaction: %v", err)
+	}
+
+	_, err = builder.Simulate(context.Background(), tx.Transaction)
+	txErr, ok := err.(*TransactionError)
+	if !ok {
+		t.Fatalf("expected a *TransactionError, got %v", err)
+	}
+	if txErr.Name != "InvalidAccountData" || !txErr.Program.Equals(solana.ComputeBudget) {
+		t.Fatalf("expected the compute budget instruction to fail with InvalidAccountData, got %v", txErr)
+	}
+}